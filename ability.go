@@ -0,0 +1,165 @@
+package main
+
+import (
+	"fmt"
+	"math"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+const MAX_ABILITIES int = 4
+const BOOST_SPEED float32 = 9
+const TIME_SLOW_SCALE float32 = 0.4
+const GRAVITY_BOMB_RADIUS float32 = 22
+const GRAVITY_BOMB_DISTANCE float32 = 60
+const GRAVITY_BOMB_FORCE_SCALE float32 = 0.3 // of a natural hole's pull
+
+type AbilityKind int
+
+const (
+	BoostAbility AbilityKind = iota
+	TimeSlowAbility
+	GravityBombAbility
+)
+
+// Data definition of an activated ability
+type Ability struct {
+	name     string
+	icon     string // short label drawn in the HUD
+	cooldown int32  // ticks before it can be used again
+	duration int32  // ticks the effect lasts, 0 for instant
+	color    rl.Color
+}
+
+// Indexed by AbilityKind
+var abilityDefinitions = []Ability{
+	BoostAbility: {
+		name:     "Afterburner",
+		icon:     "B",
+		cooldown: 240,
+		duration: 20,
+		color:    rl.Orange,
+	},
+	TimeSlowAbility: {
+		name:     "Bullet time",
+		icon:     "T",
+		cooldown: 900,
+		duration: 180,
+		color:    rl.SkyBlue,
+	},
+	GravityBombAbility: {
+		name:     "Gravity bomb",
+		icon:     "G",
+		cooldown: 600,
+		duration: 0,
+		color:    rl.Purple,
+	},
+}
+
+// Per-ship state of an equipped ability
+type AbilitySlot struct {
+	kind          AbilityKind
+	cooldownTimer int32
+	activeTimer   int32
+}
+
+func initAbilitySlots(kinds []AbilityKind) []AbilitySlot {
+	slots := []AbilitySlot{}
+	for _, kind := range kinds[:min(len(kinds), MAX_ABILITIES)] {
+		slots = append(slots, AbilitySlot{kind: kind})
+	}
+	return slots
+}
+
+func (a *AbilitySlot) definition() Ability {
+	return abilityDefinitions[a.kind]
+}
+
+func (a *AbilitySlot) isReady() bool {
+	return a.cooldownTimer <= 0
+}
+
+func (a *AbilitySlot) isActive() bool {
+	return a.activeTimer > 0
+}
+
+func (a *AbilitySlot) update() {
+	a.cooldownTimer = max(0, a.cooldownTimer-1)
+	a.activeTimer = max(0, a.activeTimer-1)
+}
+
+// Whether the given ability is currently in effect on the ship
+func (s *Ship) isAbilityActive(kind AbilityKind) bool {
+	for _, slot := range s.abilities {
+		if slot.kind == kind && slot.isActive() {
+			return true
+		}
+	}
+	return false
+}
+
+// Trigger the ability in a slot if it's off cooldown
+func (s *Ship) activateAbility(i int) {
+	if i >= len(s.abilities) || !s.abilities[i].isReady() {
+		return
+	}
+	slot := &s.abilities[i]
+	definition := slot.definition()
+	slot.cooldownTimer = definition.cooldown
+	slot.activeTimer = definition.duration
+
+	switch slot.kind {
+	case GravityBombAbility:
+		// Drop a small, short-lived black hole behind the ship to catch pursuers
+		behind := rl.Vector2Subtract(s.pos, headingVelocity(float64(s.angle), GRAVITY_BOMB_DISTANCE))
+		bomb := initBlackHole(s.game, behind, GRAVITY_BOMB_RADIUS)
+		bomb.formationCounter = FORMATION_TICKS / 6
+		bomb.updateDeathRadius()
+		bomb.isBomb = true
+		bomb.updateForce()
+		s.game.blackHoleList = append(s.game.blackHoleList, bomb)
+	}
+}
+
+// Afterburner velocity along the heading while boosting
+func (s *Ship) boostVelocity() rl.Vector2 {
+	if !s.isAbilityActive(BoostAbility) {
+		return rl.Vector2{}
+	}
+	return headingVelocity(float64(s.angle), BOOST_SPEED)
+}
+
+// How fast the world runs relative to the ship
+func (g *Game) timeScale() float32 {
+	for _, ship := range g.ships {
+		if !ship.isDead && ship.isAbilityActive(TimeSlowAbility) {
+			return TIME_SLOW_SCALE
+		}
+	}
+	return 1
+}
+
+// HUD icons with a cooldown sweep, along the bottom of the screen from left
+func (s *Ship) renderAbilityIcons(left int32) {
+	size := int32(48)
+	keys := s.game.keyBindings(s.player).abilities
+	for i, slot := range s.abilities {
+		definition := slot.definition()
+		x := left + int32(i)*(size+10)
+		y := int32(s.game.height) - size - 30
+
+		rl.DrawRectangle(x, y, size, size, rl.Fade(definition.color, 0.35))
+		if !slot.isReady() {
+			fraction := float32(slot.cooldownTimer) / float32(definition.cooldown)
+			rl.DrawRectangle(x, y, size, int32(float32(size)*fraction), rl.Fade(rl.Black, 0.6))
+		}
+		if slot.isActive() {
+			pulse := float32(math.Abs(math.Sin(float64(slot.activeTimer) * math.Pi / 10)))
+			rl.DrawRectangleLines(x-2, y-2, size+4, size+4, rl.Fade(rl.White, pulse))
+		}
+		rl.DrawRectangleLines(x, y, size, size, definition.color)
+		rl.DrawText(definition.icon, x+size/2-rl.MeasureText(definition.icon, 32)/2, y+8, 32, rl.RayWhite)
+		key := fmt.Sprintf("%c", rune(keys[i]))
+		rl.DrawText(key, x+size/2-rl.MeasureText(key, 16)/2, y+size+4, 16, rl.RayWhite)
+	}
+}
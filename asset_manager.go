@@ -0,0 +1,294 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// A named frame of a texture; the asset manager updates it in place when the image or its atlas changes
+type Sprite struct {
+	image   string
+	frame   string // "" for the whole image
+	texture *rl.Texture2D
+	source  rl.Rectangle
+}
+
+func (s *Sprite) width() float32 {
+	return s.source.Width
+}
+
+func (s *Sprite) height() float32 {
+	return s.source.Height
+}
+
+func (s *Sprite) draw(dest rl.Rectangle, origin rl.Vector2, rotation float32, tint rl.Color) {
+	rl.DrawTexturePro(*s.texture, s.source, dest, origin, rotation, tint)
+}
+
+// A sprite with no texture behind it, for games that never draw
+func emptySprite() *Sprite {
+	return &Sprite{texture: &rl.Texture2D{}}
+}
+
+// Sidecar describing the frames packed into an image, e.g. images/ship.atlas.json beside images/ship.png
+type AtlasFile struct {
+	Frames map[string]AtlasFrame `json:"frames"`
+}
+
+type AtlasFrame struct {
+	X      float32 `json:"x"`
+	Y      float32 `json:"y"`
+	Width  float32 `json:"width"`
+	Height float32 `json:"height"`
+}
+
+func atlasName(image string) string {
+	return strings.TrimSuffix(image, path.Ext(image)) + ".atlas.json"
+}
+
+// Frames of an image's atlas, or nil if it has none
+func (l *AssetLoader) loadAtlas(image string) (map[string]rl.Rectangle, error) {
+	data, _, err := l.read(atlasName(image))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var file AtlasFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, err
+	}
+	frames := map[string]rl.Rectangle{}
+	for name, f := range file.Frames {
+		if f.Width <= 0 || f.Height <= 0 || f.X < 0 || f.Y < 0 {
+			return nil, fmt.Errorf("frame %q has an empty or negative rectangle", name)
+		}
+		frames[name] = rl.NewRectangle(f.X, f.Y, f.Width, f.Height)
+	}
+	return frames, nil
+}
+
+type managedTexture struct {
+	texture rl.Texture2D
+	refs    int
+	atlas   map[string]rl.Rectangle
+	sprites map[string]*Sprite // by frame
+}
+
+type managedSound struct {
+	sound rl.Sound
+	refs  int
+}
+
+// Shares loaded textures and sounds by name, unloading each when its last user releases it, and reloads changed files
+type AssetManager struct {
+	loader   AssetLoader
+	textures map[string]*managedTexture
+	sounds   map[string]*managedSound
+	watcher  *AssetWatcher
+	log      io.Writer
+
+	// Called with a sound's name just before it's replaced by a reload
+	beforeSoundReload func(name string)
+}
+
+func initAssetManager(overrideDir string, watch bool, log io.Writer) *AssetManager {
+	m := &AssetManager{
+		loader:   initAssetLoader(overrideDir),
+		textures: map[string]*managedTexture{},
+		sounds:   map[string]*managedSound{},
+		log:      log,
+	}
+	if watch && overrideDir != "" {
+		m.watcher = startAssetWatcher(overrideDir)
+	}
+	return m
+}
+
+// A frame of an image, loading the image the first time it's asked for
+func (m *AssetManager) acquireSprite(image string, frame string) *Sprite {
+	t, ok := m.textures[image]
+	if !ok {
+		t = &managedTexture{texture: m.loader.texture(image), sprites: map[string]*Sprite{}}
+		t.atlas = m.atlas(image)
+		m.textures[image] = t
+	}
+	t.refs += 1
+
+	sprite, ok := t.sprites[frame]
+	if !ok {
+		sprite = &Sprite{image: image, frame: frame, texture: &t.texture}
+		t.sprites[frame] = sprite
+		m.frameSource(t, sprite)
+	}
+	return sprite
+}
+
+func (m *AssetManager) releaseSprite(s *Sprite) {
+	t, ok := m.textures[s.image]
+	if !ok {
+		return
+	}
+	t.refs -= 1
+	if t.refs <= 0 {
+		rl.UnloadTexture(t.texture)
+		delete(m.textures, s.image)
+	}
+}
+
+// A sound shared by everyone who acquires the same name
+func (m *AssetManager) acquireSound(name string) *rl.Sound {
+	s, ok := m.sounds[name]
+	if !ok {
+		s = &managedSound{sound: m.loader.sound(name)}
+		m.sounds[name] = s
+	}
+	s.refs += 1
+	return &s.sound
+}
+
+func (m *AssetManager) releaseSound(name string) {
+	s, ok := m.sounds[name]
+	if !ok {
+		return
+	}
+	s.refs -= 1
+	if s.refs <= 0 {
+		rl.UnloadSound(s.sound)
+		delete(m.sounds, name)
+	}
+}
+
+func (m *AssetManager) atlas(image string) map[string]rl.Rectangle {
+	frames, err := m.loader.loadAtlas(image)
+	if err != nil {
+		entry := AssetEntry{name: atlasName(image), kind: AtlasAsset}
+		m.loader.results = append(m.loader.results, AssetLoadResult{entry: entry, source: "whole image", err: err})
+	}
+	return frames
+}
+
+// Point a sprite at its atlas frame, or the whole texture if the frame isn't there
+func (m *AssetManager) frameSource(t *managedTexture, s *Sprite) {
+	whole := rl.NewRectangle(0, 0, float32(t.texture.Width), float32(t.texture.Height))
+	s.source = whole
+	if s.frame == "" || t.atlas == nil {
+		return
+	}
+	source, ok := t.atlas[s.frame]
+	if !ok {
+		fmt.Fprintf(m.log, "assets: %s has no frame %q, drawing the whole image\n", atlasName(s.image), s.frame)
+		return
+	}
+	if source.X+source.Width > whole.Width || source.Y+source.Height > whole.Height {
+		fmt.Fprintf(m.log, "assets: frame %q of %s runs off the image, drawing the whole image\n", s.frame, atlasName(s.image))
+		return
+	}
+	s.source = source
+}
+
+// Apply any file changes the watcher has seen; call once a frame from the main thread
+func (m *AssetManager) update() {
+	if m.watcher == nil {
+		return
+	}
+	for {
+		select {
+		case name := <-m.watcher.changes:
+			m.reload(name)
+		default:
+			return
+		}
+	}
+}
+
+// Swap in a changed file for everyone using it, keeping the old version if the new one doesn't load
+func (m *AssetManager) reload(name string) {
+	if t, ok := m.textures[name]; ok {
+		texture, source, err := m.loader.loadTexture(name)
+		if err != nil {
+			fmt.Fprintf(m.log, "assets: couldn't reload %s, keeping the old one: %v\n", name, err)
+			return
+		}
+		rl.UnloadTexture(t.texture)
+		t.texture = texture
+		m.refreshSprites(t)
+		fmt.Fprintf(m.log, "assets: reloaded %s from %s\n", name, source)
+		return
+	}
+
+	if s, ok := m.sounds[name]; ok {
+		sound, source, err := m.loader.loadSound(name)
+		if err != nil {
+			fmt.Fprintf(m.log, "assets: couldn't reload %s, keeping the old one: %v\n", name, err)
+			return
+		}
+		if m.beforeSoundReload != nil {
+			m.beforeSoundReload(name)
+		}
+		rl.StopSound(s.sound)
+		rl.UnloadSound(s.sound)
+		s.sound = sound
+		fmt.Fprintf(m.log, "assets: reloaded %s from %s\n", name, source)
+		return
+	}
+
+	for image, t := range m.textures {
+		if atlasName(image) != name {
+			continue
+		}
+		frames, err := m.loader.loadAtlas(image)
+		if err != nil {
+			fmt.Fprintf(m.log, "assets: couldn't reload %s, keeping the old frames: %v\n", name, err)
+			return
+		}
+		t.atlas = frames
+		m.refreshSprites(t)
+		fmt.Fprintf(m.log, "assets: reloaded %s\n", name)
+		return
+	}
+}
+
+func (m *AssetManager) refreshSprites(t *managedTexture) {
+	for _, sprite := range t.sprites {
+		m.frameSource(t, sprite)
+	}
+}
+
+// Report what failed to load so far
+func (m *AssetManager) report() {
+	m.loader.report(m.log)
+}
+
+// Stop watching and unload anything still held, naming it since it should have been released
+func (m *AssetManager) close() {
+	if m.watcher != nil {
+		m.watcher.close()
+	}
+
+	leaked := []string{}
+	for name, t := range m.textures {
+		rl.UnloadTexture(t.texture)
+		leaked = append(leaked, name)
+	}
+	for name, s := range m.sounds {
+		rl.UnloadSound(s.sound)
+		leaked = append(leaked, name)
+	}
+	if len(leaked) > 0 {
+		sort.Strings(leaked)
+		fmt.Fprintf(m.log, "assets: still in use at shutdown: %s\n", strings.Join(leaked, ", "))
+	}
+	m.textures = map[string]*managedTexture{}
+	m.sounds = map[string]*managedSound{}
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const ASSET_POLL_INTERVAL = 500 * time.Millisecond
+
+// Extensions worth reloading when they change
+var watchedAssetExtensions = []string{".png", ".wav", ".json"}
+
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// Polls the override directory and reports asset names (relative to it, with forward slashes) whose files change
+type AssetWatcher struct {
+	dir     string
+	changes chan string
+	stop    chan struct{}
+}
+
+func startAssetWatcher(dir string) *AssetWatcher {
+	w := &AssetWatcher{
+		dir:     dir,
+		changes: make(chan string, 64),
+		stop:    make(chan struct{}),
+	}
+	go w.watch()
+	return w
+}
+
+func (w *AssetWatcher) watch() {
+	// The first scan only learns what's there
+	seen := w.scan()
+	ticker := time.NewTicker(ASSET_POLL_INTERVAL)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.stop:
+			return
+		case <-ticker.C:
+		}
+
+		current := w.scan()
+		changed := []string{}
+		for name, stamp := range current {
+			if previous, ok := seen[name]; !ok || previous != stamp {
+				changed = append(changed, name)
+			}
+		}
+		// A deleted override falls back to the embedded copy
+		for name := range seen {
+			if _, ok := current[name]; !ok {
+				changed = append(changed, name)
+			}
+		}
+		seen = current
+
+		for _, name := range changed {
+			select {
+			case w.changes <- name:
+			case <-w.stop:
+				return
+			}
+		}
+	}
+}
+
+func (w *AssetWatcher) scan() map[string]fileStamp {
+	stamps := map[string]fileStamp{}
+	filepath.WalkDir(w.dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !isWatchedAsset(p) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		name, err := filepath.Rel(w.dir, p)
+		if err != nil {
+			return nil
+		}
+		stamps[filepath.ToSlash(name)] = fileStamp{modTime: info.ModTime(), size: info.Size()}
+		return nil
+	})
+	return stamps
+}
+
+func isWatchedAsset(p string) bool {
+	ext := strings.ToLower(filepath.Ext(p))
+	for _, watched := range watchedAssetExtensions {
+		if ext == watched {
+			return true
+		}
+	}
+	return false
+}
+
+func (w *AssetWatcher) close() {
+	close(w.stop)
+}
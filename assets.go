@@ -0,0 +1,288 @@
+package main
+
+import (
+	"bytes"
+	"embed"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// Copied into the binary so the game runs from any directory
+//
+//go:embed assets
+var embeddedAssets embed.FS
+
+const PLACEHOLDER_TEXTURE_SIZE int = 64
+const PLACEHOLDER_SOUND_SECONDS float32 = 0.5
+const PLACEHOLDER_SAMPLE_RATE int = 44100
+
+type AssetKind int
+
+const (
+	TextureAsset AssetKind = iota
+	SoundAsset
+	AtlasAsset
+	MusicAsset
+)
+
+func (k AssetKind) name() string {
+	switch k {
+	case SoundAsset:
+		return "sound"
+	case AtlasAsset:
+		return "atlas"
+	case MusicAsset:
+		return "music"
+	default:
+		return "texture"
+	}
+}
+
+// One file the game loads, named by its path under assets/
+type AssetEntry struct {
+	name     string
+	kind     AssetKind
+	required bool // the game looks broken without it; optional assets fall back quietly
+}
+
+// Everything the game loads at startup
+var assetManifest = []AssetEntry{
+	{name: "images/background.png", kind: TextureAsset, required: true},
+	{name: "images/ship.png", kind: TextureAsset, required: true},
+	{name: "images/asteroid.png", kind: TextureAsset, required: true},
+	{name: "images/black_hole.png", kind: TextureAsset, required: true},
+	{name: "images/star.png", kind: TextureAsset, required: true},
+	{name: "sound/explosion.wav", kind: SoundAsset, required: true},
+	{name: "sound/engine.wav", kind: SoundAsset, required: true},
+	{name: "sound/music_menu.wav", kind: MusicAsset, required: false},
+	{name: "sound/music_play.wav", kind: MusicAsset, required: false},
+	{name: "sound/music_game_over.wav", kind: MusicAsset, required: false},
+}
+
+func findAssetEntry(name string) (AssetEntry, bool) {
+	for _, entry := range assetManifest {
+		if entry.name == name {
+			return entry, true
+		}
+	}
+	return AssetEntry{}, false
+}
+
+// Where an asset came from, or why it didn't load
+type AssetLoadResult struct {
+	entry  AssetEntry
+	source string // "override", "embedded", or the fallback used
+	err    error
+}
+
+// Loads assets from the override directory or the embedded copy, substituting placeholders for anything that fails
+type AssetLoader struct {
+	overrideDir string
+	results     []AssetLoadResult
+}
+
+func initAssetLoader(overrideDir string) AssetLoader {
+	return AssetLoader{overrideDir: overrideDir}
+}
+
+// Path of the file replacing an asset, if the override directory has one
+func (l *AssetLoader) overrideFile(name string) (string, bool) {
+	if l.overrideDir == "" {
+		return "", false
+	}
+	file := filepath.Join(l.overrideDir, filepath.FromSlash(name))
+	info, err := os.Stat(file)
+	return file, err == nil && !info.IsDir()
+}
+
+// The raw bytes of an asset and where they were found
+func (l *AssetLoader) read(name string) ([]byte, string, error) {
+	if l.overrideDir != "" {
+		data, err := os.ReadFile(filepath.Join(l.overrideDir, filepath.FromSlash(name)))
+		if err == nil {
+			return data, "override", nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return nil, "", err
+		}
+	}
+	data, err := embeddedAssets.ReadFile(path.Join("assets", name))
+	if err != nil {
+		return nil, "", fs.ErrNotExist
+	}
+	return data, "embedded", nil
+}
+
+func (l *AssetLoader) entry(name string, kind AssetKind) AssetEntry {
+	entry, ok := findAssetEntry(name)
+	if !ok {
+		// Still load it, but flag it so the manifest gets updated
+		entry = AssetEntry{name: name, kind: kind, required: true}
+		l.results = append(l.results, AssetLoadResult{entry: entry, source: "file anyway", err: errors.New("not in the asset manifest")})
+	}
+	return entry
+}
+
+// Decode a texture without substituting a placeholder on failure
+func (l *AssetLoader) loadTexture(name string) (rl.Texture2D, string, error) {
+	data, source, err := l.read(name)
+	if err != nil {
+		return rl.Texture2D{}, "", err
+	}
+	image := rl.LoadImageFromMemory(strings.ToLower(path.Ext(name)), data, int32(len(data)))
+	if !rl.IsImageValid(image) {
+		return rl.Texture2D{}, "", fmt.Errorf("can't decode %s data", source)
+	}
+	texture := rl.LoadTextureFromImage(image)
+	rl.UnloadImage(image)
+	return texture, source, nil
+}
+
+// Decode a sound without substituting a placeholder on failure
+func (l *AssetLoader) loadSound(name string) (rl.Sound, string, error) {
+	data, source, err := l.read(name)
+	if err != nil {
+		return rl.Sound{}, "", err
+	}
+	wave := rl.LoadWaveFromMemory(strings.ToLower(path.Ext(name)), data, int32(len(data)))
+	if !rl.IsWaveValid(wave) {
+		return rl.Sound{}, "", fmt.Errorf("can't decode %s data", source)
+	}
+	sound := rl.LoadSoundFromWave(wave)
+	rl.UnloadWave(wave)
+	return sound, source, nil
+}
+
+// Record whether an asset loaded later on demand can be found, naming what stands in for it if not
+func (l *AssetLoader) check(name string, kind AssetKind, fallback string) {
+	entry := l.entry(name, kind)
+	_, source, err := l.read(name)
+	if err != nil {
+		source = fallback
+	}
+	l.results = append(l.results, AssetLoadResult{entry: entry, source: source, err: err})
+}
+
+func (l *AssetLoader) texture(name string) rl.Texture2D {
+	entry := l.entry(name, TextureAsset)
+	texture, source, err := l.loadTexture(name)
+	if err != nil {
+		l.results = append(l.results, AssetLoadResult{entry: entry, source: "placeholder", err: err})
+		return placeholderTexture()
+	}
+	l.results = append(l.results, AssetLoadResult{entry: entry, source: source})
+	return texture
+}
+
+func (l *AssetLoader) sound(name string) rl.Sound {
+	entry := l.entry(name, SoundAsset)
+	sound, source, err := l.loadSound(name)
+	if err != nil {
+		l.results = append(l.results, AssetLoadResult{entry: entry, source: "placeholder", err: err})
+		return placeholderSound()
+	}
+	l.results = append(l.results, AssetLoadResult{entry: entry, source: source})
+	return sound
+}
+
+// Manifest entries the loader hasn't been asked for, so typos in names show up
+func (l *AssetLoader) unused() []AssetEntry {
+	unused := []AssetEntry{}
+	for _, entry := range assetManifest {
+		loaded := false
+		for _, result := range l.results {
+			if result.entry.name == entry.name {
+				loaded = true
+			}
+		}
+		if !loaded {
+			unused = append(unused, entry)
+		}
+	}
+	return unused
+}
+
+// Log what failed to load, loudest for required assets; silent when everything loaded
+func (l *AssetLoader) report(w io.Writer) {
+	failures := 0
+	for _, result := range l.results {
+		if result.err != nil {
+			failures += 1
+		}
+	}
+	unused := l.unused()
+	if failures == 0 && len(unused) == 0 {
+		return
+	}
+
+	fmt.Fprintf(w, "assets: %d problem(s) loading from %s\n", failures+len(unused), l.describeSources())
+	for _, result := range l.results {
+		if result.err == nil {
+			continue
+		}
+		level := "warning"
+		if result.entry.required {
+			level = "error"
+		}
+		err := result.err
+		if errors.Is(err, fs.ErrNotExist) {
+			err = errors.New("file not found")
+		}
+		fmt.Fprintf(w, "  %s: %s %s: %v (using the %s)\n", level, result.entry.kind.name(), result.entry.name, err, result.source)
+	}
+	for _, entry := range unused {
+		fmt.Fprintf(w, "  warning: %s %s is in the manifest but never loaded\n", entry.kind.name(), entry.name)
+	}
+}
+
+func (l *AssetLoader) describeSources() string {
+	if l.overrideDir == "" {
+		return "the embedded assets"
+	}
+	return fmt.Sprintf("%s and the embedded assets", l.overrideDir)
+}
+
+// Magenta and black checks, impossible to mistake for real art
+func placeholderTexture() rl.Texture2D {
+	image := rl.GenImageChecked(PLACEHOLDER_TEXTURE_SIZE, PLACEHOLDER_TEXTURE_SIZE, PLACEHOLDER_TEXTURE_SIZE/4, PLACEHOLDER_TEXTURE_SIZE/4, rl.Magenta, rl.Black)
+	texture := rl.LoadTextureFromImage(image)
+	rl.UnloadImage(image)
+	return texture
+}
+
+func placeholderSound() rl.Sound {
+	data := silentWAV(int(PLACEHOLDER_SOUND_SECONDS * float32(PLACEHOLDER_SAMPLE_RATE)))
+	wave := rl.LoadWaveFromMemory(".wav", data, int32(len(data)))
+	sound := rl.LoadSoundFromWave(wave)
+	rl.UnloadWave(wave)
+	return sound
+}
+
+// A mono 16-bit WAV file of silence
+func silentWAV(frames int) []byte {
+	dataSize := frames * 2
+	var b bytes.Buffer
+	b.WriteString("RIFF")
+	binary.Write(&b, binary.LittleEndian, uint32(36+dataSize))
+	b.WriteString("WAVEfmt ")
+	binary.Write(&b, binary.LittleEndian, uint32(16))                        // format chunk size
+	binary.Write(&b, binary.LittleEndian, uint16(1))                         // PCM
+	binary.Write(&b, binary.LittleEndian, uint16(1))                         // channels
+	binary.Write(&b, binary.LittleEndian, uint32(PLACEHOLDER_SAMPLE_RATE))   // sample rate
+	binary.Write(&b, binary.LittleEndian, uint32(PLACEHOLDER_SAMPLE_RATE*2)) // byte rate
+	binary.Write(&b, binary.LittleEndian, uint16(2))                         // block align
+	binary.Write(&b, binary.LittleEndian, uint16(16))                        // bits per sample
+	b.WriteString("data")
+	binary.Write(&b, binary.LittleEndian, uint32(dataSize))
+	b.Write(make([]byte, dataSize))
+	return b.Bytes()
+}
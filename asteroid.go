@@ -1,57 +1,91 @@
 package main
 
 import (
-	"image/color"
+	"math"
 
 	rl "github.com/gen2brain/raylib-go/raylib"
 )
 
+const FRAGMENT_SPEED float32 = 3.0
+const CRUSH_CLEARANCE float32 = 4.0
+
 type Asteroid struct {
 	game       *Game
-	pos        rl.Vector2
+	id         int32      // unique within a run
+	pos        rl.Vector2 // center, so collisions don't depend on the sprite
 	radius     float32
 	velocity   rl.Vector2
+	class      AsteroidClassKind
 	vaporTrail []rl.Vector3
 	isAlive    bool
 }
 
-func initAsteroid(g *Game, p rl.Vector2, r float32, v rl.Vector2) Asteroid {
+func initAsteroid(g *Game, p rl.Vector2, c AsteroidClassKind, v rl.Vector2) Asteroid {
+	g.nextAsteroidID += 1
 	return Asteroid{
 		game:       g,
+		id:         g.nextAsteroidID,
 		pos:        p,
-		radius:     r,
+		radius:     asteroidClasses[c].radius,
 		velocity:   v,
+		class:      c,
 		vaporTrail: []rl.Vector3{},
 		isAlive:    true,
 	}
 }
 
 func (a *Asteroid) render() {
-	rl.DrawTexture(a.game.asteroidTexture, int32(a.pos.X), int32(a.pos.Y), rl.White)
-
+	class := asteroidClasses[a.class]
 	for _, dot := range a.vaporTrail {
-		rl.DrawCircle(int32(dot.X), int32(dot.Y), dot.Z, color.RGBA{255, 190, 51, 100})
+		rl.DrawCircle(int32(dot.X), int32(dot.Y), dot.Z, class.trailColor)
 	}
+
+	sprite := a.game.asteroidSprite
+	scale := a.textureScale()
+	width := sprite.width() * scale
+	height := sprite.height() * scale
+	sprite.draw(
+		rl.NewRectangle(a.pos.X, a.pos.Y, width, height),
+		rl.Vector2{X: width / 2, Y: height / 2},
+		0,
+		class.tint,
+	)
 }
 
 func (a *Asteroid) update() {
+	// Already destroyed this tick, e.g. by a shockwave
+	if !a.isAlive {
+		return
+	}
+
 	// Remove if we've gone out of bounds
-	if a.pos.Y < -50 || a.pos.Y > float32(WindowHeight+50) || a.pos.X < -50 || a.pos.X > float32(WindowWidth+50) {
+	if a.pos.Y < -50 || a.pos.Y > float32(a.game.height+50) || a.pos.X < -50 || a.pos.X > float32(a.game.width+50) {
 		a.isAlive = false
 		return
 	}
 
+	class := asteroidClasses[a.class]
+
 	// Check to see if we've been crushed
-	for _, b := range a.game.blackHoleList {
+	for i := range a.game.blackHoleList {
+		b := &a.game.blackHoleList[i]
 		if rl.CheckCollisionCircles(a.pos, a.radius, b.pos, b.deathRadius) {
-			a.isAlive = false
-			a.game.createNewExplosion(a.pos, 15)
+			b.consume(class.mass * ASTEROID_MASS_RATIO)
+			a.crush(b)
 			return
 		}
+	}
+
+	// Calculate velocity updates from the gravitational field, heavier rocks respond less
+	force := a.game.calculateFieldForce(a.pos)
+	a.velocity = rl.Vector2Add(a.velocity, rl.Vector2Scale(force, 1/class.mass))
 
-		// Calculate velocity updates from black hole
-		force := b.calculateForceOnObject(a.pos)
-		a.velocity = rl.Vector2Add(a.velocity, force)
+	// Pass through any wormhole we've drifted into
+	for _, wormhole := range a.game.wormholeList {
+		if exit, ok := wormhole.teleport(a.pos, a.velocity); ok {
+			a.pos = exit
+			break
+		}
 	}
 
 	// Calculate the new position
@@ -60,28 +94,70 @@ func (a *Asteroid) update() {
 	// Update the vapor trail
 	newVaporTrail := []rl.Vector3{}
 	for _, dot := range a.vaporTrail {
-		dot.Z -= 0.1
+		dot.Z -= class.trailDecay
 		if dot.Z > 0 {
 			newVaporTrail = append(newVaporTrail, dot)
 		}
 	}
 
 	// Add a new dot to the trail
-	t := a.game.asteroidTexture
+	center := a.getCollisionCircle()
 	newVaporTrail = append(newVaporTrail, rl.Vector3{
-		X: a.pos.X + float32(t.Width)/2,
-		Y: a.pos.Y + float32(t.Height)/2,
-		Z: 3.0,
+		X: center.X,
+		Y: center.Y,
+		Z: class.trailSize,
 	})
 	a.vaporTrail = newVaporTrail
 
 }
 
-func (a *Asteroid) getCollisionCircle() rl.Vector3 {
-	t := a.game.asteroidTexture
-	return rl.Vector3{
-		X: a.pos.X + (float32(t.Width) / 2),
-		Y: a.pos.Y + (float32(t.Height) / 2),
-		Z: a.radius,
+// Destroy the asteroid, splitting it into fragments if its class allows
+func (a *Asteroid) destroy() {
+	class := asteroidClasses[a.class]
+	a.isAlive = false
+	a.game.createNewExplosion(a.pos, 15)
+
+	if a.game.anyShipAlive() {
+		a.game.awardPoints(-1, class.score)
 	}
+
+	// Leave fuel behind for the taking
+	if a.game.fuelMode {
+		a.game.dropDebris(a.pos, a.velocity, max(1, int(class.mass*2)))
+	}
+
+	// Fling the fragments outward so they don't fall straight back in
+	offset := a.game.rng.Float64() * 2 * math.Pi
+	for i := range class.fragmentCount {
+		angle := offset + 2*math.Pi*float64(i)/float64(class.fragmentCount)
+		direction := rl.Vector2{X: float32(math.Cos(angle)), Y: float32(math.Sin(angle))}
+		pos := rl.Vector2Add(a.pos, rl.Vector2Scale(direction, class.radius))
+		velocity := rl.Vector2Add(a.velocity, rl.Vector2Scale(direction, FRAGMENT_SPEED))
+		a.game.fragmentList = append(a.game.fragmentList, initAsteroid(a.game, pos, class.fragmentClass, velocity))
+	}
+}
+
+// Break up a rock caught by a black hole, scattering the pieces from just outside the death radius so they aren't crushed on the next tick
+func (a *Asteroid) crush(b *BlackHole) {
+	out := rl.Vector2Normalize(rl.Vector2Subtract(a.pos, b.pos))
+	if rl.Vector2Length(out) == 0 {
+		out = rl.Vector2{X: 1, Y: 0}
+	}
+	class := asteroidClasses[a.class]
+	clearance := b.deathRadius + class.radius + asteroidClasses[class.fragmentClass].radius + CRUSH_CLEARANCE
+	a.pos = rl.Vector2Add(b.pos, rl.Vector2Scale(out, clearance))
+
+	// Keep the sideways motion but not the fall inward, so even the innermost fragment isn't heading back in
+	inward := min(0, rl.Vector2DotProduct(a.velocity, out))
+	a.velocity = rl.Vector2Add(rl.Vector2Subtract(a.velocity, rl.Vector2Scale(out, inward)), rl.Vector2Scale(out, FRAGMENT_SPEED))
+	a.destroy()
+}
+
+// Texture scale relative to the standard asteroid
+func (a *Asteroid) textureScale() float32 {
+	return a.radius / asteroidClasses[StandardAsteroid].radius
+}
+
+func (a *Asteroid) getCollisionCircle() rl.Vector3 {
+	return rl.Vector3{X: a.pos.X, Y: a.pos.Y, Z: a.radius}
 }
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"image/color"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+type AsteroidClassKind int
+
+const (
+	StandardAsteroid AsteroidClassKind = iota
+	SmallAsteroid
+	LargeAsteroid
+	HeavyAsteroid
+	Comet
+)
+
+// Data definition of an asteroid class
+type AsteroidClass struct {
+	name          string
+	radius        float32
+	speedScale    float32 // multiplier on the launch speed
+	mass          float32 // gravity is divided by this
+	score         int32   // awarded when a black hole destroys it
+	tint          rl.Color
+	trailColor    rl.Color
+	trailSize     float32
+	trailDecay    float32
+	fragmentClass AsteroidClassKind
+	fragmentCount int // 0 if the asteroid doesn't split
+}
+
+// Indexed by AsteroidClassKind
+var asteroidClasses = []AsteroidClass{
+	StandardAsteroid: {
+		name:       "standard",
+		radius:     10,
+		speedScale: 1,
+		mass:       1,
+		score:      100,
+		tint:       rl.White,
+		trailColor: color.RGBA{255, 190, 51, 100},
+		trailSize:  3,
+		trailDecay: 0.1,
+	},
+	SmallAsteroid: {
+		name:       "small",
+		radius:     6,
+		speedScale: 1.5,
+		mass:       0.6,
+		score:      150,
+		tint:       color.RGBA{255, 220, 200, 255},
+		trailColor: color.RGBA{255, 150, 51, 100},
+		trailSize:  2,
+		trailDecay: 0.15,
+	},
+	LargeAsteroid: {
+		name:          "large",
+		radius:        18,
+		speedScale:    0.6,
+		mass:          2,
+		score:         200,
+		tint:          color.RGBA{200, 180, 160, 255},
+		trailColor:    color.RGBA{255, 190, 51, 100},
+		trailSize:     5,
+		trailDecay:    0.1,
+		fragmentClass: SmallAsteroid,
+		fragmentCount: 3,
+	},
+	HeavyAsteroid: {
+		name:       "heavy",
+		radius:     13,
+		speedScale: 0.8,
+		mass:       4,
+		score:      250,
+		tint:       color.RGBA{140, 140, 160, 255},
+		trailColor: color.RGBA{200, 200, 220, 100},
+		trailSize:  3,
+		trailDecay: 0.1,
+	},
+	Comet: {
+		name:       "comet",
+		radius:     7,
+		speedScale: 1.2,
+		mass:       0.4,
+		score:      300,
+		tint:       color.RGBA{180, 230, 255, 255},
+		trailColor: color.RGBA{120, 200, 255, 120},
+		trailSize:  4,
+		trailDecay: 0.02,
+	},
+}
@@ -0,0 +1,264 @@
+package main
+
+import (
+	"math"
+	"math/rand"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+const ASTEROID_EDGE_OFFSET float32 = 20
+const ASTEROID_EDGE_PADDING float32 = 40
+
+type Edge int
+
+const (
+	TopEdge Edge = iota
+	RightEdge
+	BottomEdge
+	LeftEdge
+)
+
+type PatternKind int
+
+const (
+	SingleRockPattern PatternKind = iota
+	MeteorShowerPattern
+	AimedShotPattern
+	OrbitRingPattern
+)
+
+// Pattern weight, interpolated from start to end over the first rampTicks of a run
+type PatternWeight struct {
+	start     float32
+	end       float32
+	rampTicks int32
+}
+
+// Data definition of an asteroid spawn pattern
+type AsteroidPattern struct {
+	name    string
+	kind    PatternKind
+	count   int
+	speed   rl.Vector2 // min speed, max speed
+	spread  float64    // max deviation from the base heading, in radians
+	stagger int32      // ticks between consecutive rocks
+	classes []AsteroidClassKind
+	weight  PatternWeight
+}
+
+// A single asteroid produced by a pattern
+type AsteroidLaunch struct {
+	pos      rl.Vector2
+	velocity rl.Vector2
+	class    AsteroidClassKind
+	delay    int32
+}
+
+var asteroidPatterns = []AsteroidPattern{
+	{
+		name:   "single",
+		kind:   SingleRockPattern,
+		count:  1,
+		speed:  rl.Vector2{X: 3, Y: 12},
+		spread: math.Pi / 4,
+		classes: []AsteroidClassKind{
+			StandardAsteroid, StandardAsteroid, SmallAsteroid, LargeAsteroid, HeavyAsteroid, Comet,
+		},
+		weight: PatternWeight{start: 10, end: 4, rampTicks: 7200},
+	},
+	{
+		name:    "shower",
+		kind:    MeteorShowerPattern,
+		count:   5,
+		speed:   rl.Vector2{X: 5, Y: 9},
+		spread:  math.Pi / 16,
+		stagger: 10,
+		classes: []AsteroidClassKind{SmallAsteroid},
+		weight:  PatternWeight{start: 0, end: 4, rampTicks: 7200},
+	},
+	{
+		name:    "aimed",
+		kind:    AimedShotPattern,
+		count:   1,
+		speed:   rl.Vector2{X: 7, Y: 11},
+		classes: []AsteroidClassKind{StandardAsteroid, Comet},
+		weight:  PatternWeight{start: 1, end: 4, rampTicks: 5400},
+	},
+	{
+		name:    "ring",
+		kind:    OrbitRingPattern,
+		count:   6,
+		stagger: 4,
+		classes: []AsteroidClassKind{StandardAsteroid, SmallAsteroid},
+		weight:  PatternWeight{start: 0, end: 3, rampTicks: 9000},
+	},
+}
+
+func (w PatternWeight) at(ticks int32) float32 {
+	if w.rampTicks <= 0 || ticks >= w.rampTicks {
+		return w.end
+	}
+	return w.start + (w.end-w.start)*float32(ticks)/float32(w.rampTicks)
+}
+
+func (p *AsteroidPattern) weightAt(ticks int32, blackHoleCount int) float32 {
+	// Rings need something to orbit
+	if p.kind == OrbitRingPattern && blackHoleCount == 0 {
+		return 0
+	}
+	return max(0, p.weight.at(ticks))
+}
+
+// Choose a pattern at random according to the weights for this point in the run
+func chooseAsteroidPattern(rng *rand.Rand, patterns []AsteroidPattern, ticks int32, blackHoleCount int) *AsteroidPattern {
+	total := float32(0)
+	for i := range patterns {
+		total += patterns[i].weightAt(ticks, blackHoleCount)
+	}
+	if total <= 0 {
+		return &patterns[0]
+	}
+
+	roll := rng.Float32() * total
+	for i := range patterns {
+		roll -= patterns[i].weightAt(ticks, blackHoleCount)
+		if roll < 0 {
+			return &patterns[i]
+		}
+	}
+	return &patterns[len(patterns)-1]
+}
+
+// Position just outside the given edge of a screen this size, t in [0, 1] along its length
+func edgeEntryPoint(screen rl.Vector2, e Edge, t float32) rl.Vector2 {
+	width := screen.X - 2*ASTEROID_EDGE_PADDING
+	height := screen.Y - 2*ASTEROID_EDGE_PADDING
+
+	switch e {
+	case TopEdge:
+		return rl.Vector2{X: ASTEROID_EDGE_PADDING + t*width, Y: -ASTEROID_EDGE_OFFSET}
+	case RightEdge:
+		return rl.Vector2{X: screen.X + ASTEROID_EDGE_OFFSET, Y: ASTEROID_EDGE_PADDING + t*height}
+	case BottomEdge:
+		return rl.Vector2{X: ASTEROID_EDGE_PADDING + t*width, Y: screen.Y + ASTEROID_EDGE_OFFSET}
+	default:
+		return rl.Vector2{X: -ASTEROID_EDGE_OFFSET, Y: ASTEROID_EDGE_PADDING + t*height}
+	}
+}
+
+// Heading pointing from the given edge into the screen
+func edgeInwardHeading(e Edge) float64 {
+	switch e {
+	case TopEdge:
+		return math.Pi / 2
+	case RightEdge:
+		return math.Pi
+	case BottomEdge:
+		return -math.Pi / 2
+	default:
+		return 0
+	}
+}
+
+func headingVelocity(heading float64, speed float32) rl.Vector2 {
+	return rl.Vector2{
+		X: float32(math.Cos(heading)) * speed,
+		Y: float32(math.Sin(heading)) * speed,
+	}
+}
+
+func (p *AsteroidPattern) randomSpeed(rng *rand.Rand) float32 {
+	return p.speed.X + rng.Float32()*(p.speed.Y-p.speed.X)
+}
+
+func (p *AsteroidPattern) randomClass(rng *rand.Rand) AsteroidClassKind {
+	if len(p.classes) == 0 {
+		return StandardAsteroid
+	}
+	return p.classes[rng.Intn(len(p.classes))]
+}
+
+// Build the launches for one instance of the pattern
+func (p *AsteroidPattern) generate(rng *rand.Rand, screen rl.Vector2, target rl.Vector2, targetVelocity rl.Vector2, blackHoles []BlackHole) []AsteroidLaunch {
+	launches := []AsteroidLaunch{}
+
+	switch p.kind {
+	case SingleRockPattern:
+		edge := Edge(rng.Intn(4))
+		heading := edgeInwardHeading(edge) + (rng.Float64()*2-1)*p.spread
+		class := p.randomClass(rng)
+		launches = append(launches, AsteroidLaunch{
+			pos:      edgeEntryPoint(screen, edge, rng.Float32()),
+			velocity: headingVelocity(heading, p.randomSpeed(rng)*asteroidClasses[class].speedScale),
+			class:    class,
+		})
+	case MeteorShowerPattern:
+		// Rocks spread along one edge, all travelling roughly the same way
+		edge := Edge(rng.Intn(4))
+		heading := edgeInwardHeading(edge) + (rng.Float64()*2-1)*p.spread
+		speed := p.randomSpeed(rng)
+		start := rng.Float32() * 0.5
+		for i := range p.count {
+			class := p.randomClass(rng)
+			launches = append(launches, AsteroidLaunch{
+				pos:      edgeEntryPoint(screen, edge, start+0.5*float32(i)/float32(max(1, p.count-1))),
+				velocity: headingVelocity(heading, speed*asteroidClasses[class].speedScale),
+				class:    class,
+				delay:    int32(i) * p.stagger,
+			})
+		}
+	case AimedShotPattern:
+		edge := Edge(rng.Intn(4))
+		pos := edgeEntryPoint(screen, edge, rng.Float32())
+		class := p.randomClass(rng)
+		speed := p.randomSpeed(rng) * asteroidClasses[class].speedScale
+		aim := predictInterceptPoint(pos, speed, target, targetVelocity)
+		heading := math.Atan2(float64(aim.Y-pos.Y), float64(aim.X-pos.X))
+		launches = append(launches, AsteroidLaunch{
+			pos:      pos,
+			velocity: headingVelocity(heading, speed),
+			class:    class,
+		})
+	case OrbitRingPattern:
+		if len(blackHoles) == 0 {
+			break
+		}
+		b := blackHoles[rng.Intn(len(blackHoles))]
+		orbitRadius := 3 * b.radius
+		class := p.randomClass(rng)
+		// Circular orbit speed for an inverse-square pull of force / (FUDGE_FACTOR * mass * r^2)
+		speed := float32(math.Sqrt(float64(b.force) / (FUDGE_FACTOR * float64(asteroidClasses[class].mass) * float64(orbitRadius))))
+		offset := rng.Float64() * 2 * math.Pi
+		clockwise := rng.Intn(2) > 0
+		for i := range p.count {
+			angle := offset + 2*math.Pi*float64(i)/float64(p.count)
+			tangent := angle + math.Pi/2
+			if clockwise {
+				tangent = angle - math.Pi/2
+			}
+			launches = append(launches, AsteroidLaunch{
+				pos:      rl.Vector2Add(b.pos, headingVelocity(angle, orbitRadius)),
+				velocity: headingVelocity(tangent, speed),
+				class:    class,
+				delay:    int32(i) * p.stagger,
+			})
+		}
+	}
+
+	return launches
+}
+
+// Where a moving target will be when a rock fired from pos at the given speed reaches it
+func predictInterceptPoint(pos rl.Vector2, speed float32, target rl.Vector2, targetVelocity rl.Vector2) rl.Vector2 {
+	aim := target
+	if speed <= 0 {
+		return aim
+	}
+	// A few refinement passes are plenty for the ship's speeds
+	for range 3 {
+		ticks := rl.Vector2Distance(pos, aim) / speed
+		aim = rl.Vector2Add(target, rl.Vector2Scale(targetVelocity, ticks))
+	}
+	return aim
+}
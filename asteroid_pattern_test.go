@@ -0,0 +1,177 @@
+package main
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+var allEdges = []Edge{TopEdge, RightEdge, BottomEdge, LeftEdge}
+
+var testScreen = rl.Vector2{X: float32(DEFAULT_WINDOW_WIDTH), Y: float32(DEFAULT_WINDOW_HEIGHT)}
+
+func edgeName(e Edge) string {
+	return []string{"top", "right", "bottom", "left"}[e]
+}
+
+// Unit vector pointing from an edge into the screen
+func inwardNormal(e Edge) rl.Vector2 {
+	switch e {
+	case TopEdge:
+		return rl.Vector2{Y: 1}
+	case RightEdge:
+		return rl.Vector2{X: -1}
+	case BottomEdge:
+		return rl.Vector2{Y: -1}
+	default:
+		return rl.Vector2{X: 1}
+	}
+}
+
+// The edge a point lies just beyond, if it's off-screen by at most the entry offset
+func offscreenEdge(p rl.Vector2) (Edge, bool) {
+	w, h := testScreen.X, testScreen.Y
+	near := func(v float32, edge float32) bool {
+		return math.Abs(float64(v-edge)) <= float64(ASTEROID_EDGE_OFFSET)+0.001
+	}
+	switch {
+	case p.Y < 0 && near(p.Y, 0) && p.X >= 0 && p.X <= w:
+		return TopEdge, true
+	case p.X > w && near(p.X, w) && p.Y >= 0 && p.Y <= h:
+		return RightEdge, true
+	case p.Y > h && near(p.Y, h) && p.X >= 0 && p.X <= w:
+		return BottomEdge, true
+	case p.X < 0 && near(p.X, 0) && p.Y >= 0 && p.Y <= h:
+		return LeftEdge, true
+	}
+	return 0, false
+}
+
+func insideScreen(p rl.Vector2) bool {
+	return p.X >= 0 && p.X <= testScreen.X && p.Y >= 0 && p.Y <= testScreen.Y
+}
+
+func TestEdgeEntryPoint(t *testing.T) {
+	for _, edge := range allEdges {
+		for _, along := range []float32{0, 0.25, 0.5, 1} {
+			p := edgeEntryPoint(testScreen, edge, along)
+			got, ok := offscreenEdge(p)
+			if !ok {
+				t.Errorf("%s edge at %.2f: %v is not just off-screen", edgeName(edge), along, p)
+				continue
+			}
+			if got != edge {
+				t.Errorf("%s edge at %.2f: %v is beyond the %s edge", edgeName(edge), along, p, edgeName(got))
+			}
+		}
+	}
+}
+
+func TestEdgeInwardHeading(t *testing.T) {
+	for _, edge := range allEdges {
+		heading := headingVelocity(edgeInwardHeading(edge), 1)
+		if rl.Vector2DotProduct(heading, inwardNormal(edge)) < 0.999 {
+			t.Errorf("%s edge: heading %v doesn't point into the screen", edgeName(edge), heading)
+		}
+
+		// Two offsets along the heading should land on the screen
+		p := rl.Vector2Add(edgeEntryPoint(testScreen, edge, 0.5), rl.Vector2Scale(heading, 2*ASTEROID_EDGE_OFFSET))
+		if !insideScreen(p) {
+			t.Errorf("%s edge: entering along the heading reaches %v, still off-screen", edgeName(edge), p)
+		}
+	}
+}
+
+func TestPatternGenerate(t *testing.T) {
+	target := rl.Vector2Scale(testScreen, 0.5)
+	blackHoles := []BlackHole{{pos: rl.Vector2{X: 500, Y: 400}, radius: 45, force: 100}}
+
+	tests := []struct {
+		name string
+		kind PatternKind
+	}{
+		{"single rock", SingleRockPattern},
+		{"meteor shower", MeteorShowerPattern},
+		{"aimed shot", AimedShotPattern},
+		{"orbit ring", OrbitRingPattern},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var pattern *AsteroidPattern
+			for i := range asteroidPatterns {
+				if asteroidPatterns[i].kind == test.kind {
+					pattern = &asteroidPatterns[i]
+				}
+			}
+			if pattern == nil {
+				t.Fatalf("no pattern of this kind in asteroidPatterns")
+			}
+
+			edgesSeen := map[Edge]bool{}
+			for seed := range int64(200) {
+				rng := rand.New(rand.NewSource(seed))
+				launches := pattern.generate(rng, testScreen, target, rl.Vector2{X: 1, Y: 0}, blackHoles)
+				if len(launches) != pattern.count {
+					t.Fatalf("seed %d: %d launches, want %d", seed, len(launches), pattern.count)
+				}
+
+				for i, launch := range launches {
+					if test.kind == OrbitRingPattern {
+						checkOrbitLaunch(t, seed, i, launch, blackHoles[0])
+						continue
+					}
+
+					edge, ok := offscreenEdge(launch.pos)
+					if !ok {
+						t.Fatalf("seed %d launch %d: %v is not just off-screen", seed, i, launch.pos)
+					}
+					edgesSeen[edge] = true
+					if rl.Vector2DotProduct(launch.velocity, inwardNormal(edge)) <= 0 {
+						t.Errorf("seed %d launch %d: velocity %v from the %s edge points away from the screen", seed, i, launch.velocity, edgeName(edge))
+					}
+					if test.kind == AimedShotPattern && rl.Vector2DotProduct(launch.velocity, rl.Vector2Subtract(target, launch.pos)) <= 0 {
+						t.Errorf("seed %d: aimed shot %v heads away from the target", seed, launch.velocity)
+					}
+				}
+			}
+
+			if test.kind != OrbitRingPattern {
+				for _, edge := range allEdges {
+					if !edgesSeen[edge] {
+						t.Errorf("never entered from the %s edge", edgeName(edge))
+					}
+				}
+			}
+		})
+	}
+}
+
+// Ring rocks start on a circle around the hole, moving along it
+func checkOrbitLaunch(t *testing.T, seed int64, i int, launch AsteroidLaunch, b BlackHole) {
+	t.Helper()
+	offset := rl.Vector2Subtract(launch.pos, b.pos)
+	if math.Abs(float64(rl.Vector2Length(offset)-3*b.radius)) > 0.01 {
+		t.Errorf("seed %d launch %d: %v is %.2f from the hole, want %.2f", seed, i, launch.pos, rl.Vector2Length(offset), 3*b.radius)
+	}
+	cosine := rl.Vector2DotProduct(rl.Vector2Normalize(offset), rl.Vector2Normalize(launch.velocity))
+	if math.Abs(float64(cosine)) > 0.001 {
+		t.Errorf("seed %d launch %d: velocity %v isn't tangent to the orbit", seed, i, launch.velocity)
+	}
+}
+
+func TestOrbitRingNeedsBlackHole(t *testing.T) {
+	for i := range asteroidPatterns {
+		pattern := &asteroidPatterns[i]
+		if pattern.kind != OrbitRingPattern {
+			continue
+		}
+		if w := pattern.weightAt(1_000_000, 0); w != 0 {
+			t.Errorf("%s has weight %v with no black holes", pattern.name, w)
+		}
+		if launches := pattern.generate(rand.New(rand.NewSource(1)), testScreen, rl.Vector2{}, rl.Vector2{}, nil); len(launches) != 0 {
+			t.Errorf("%s made %d launches with no black holes", pattern.name, len(launches))
+		}
+	}
+}
@@ -0,0 +1,307 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const SURVIVAL_BUCKET_TICKS int32 = 600
+
+// What to simulate in a batch; run i uses seed firstSeed+i
+type BatchConfig struct {
+	label     string
+	bot       string
+	runs      int
+	firstSeed int64
+	workers   int // 0 for one per CPU
+	maxTicks  int32
+}
+
+type SurvivalStats struct {
+	Mean   float64 `json:"mean"`
+	Min    int32   `json:"min"`
+	P10    int32   `json:"p10"`
+	P25    int32   `json:"p25"`
+	Median int32   `json:"median"`
+	P75    int32   `json:"p75"`
+	P90    int32   `json:"p90"`
+	Max    int32   `json:"max"`
+}
+
+type SurvivalBucket struct {
+	FromTicks int32 `json:"from_ticks"`
+	ToTicks   int32 `json:"to_ticks"`
+	Runs      int   `json:"runs"`
+}
+
+// Mean entity counts across the runs still alive at a tick
+type TimelinePoint struct {
+	Tick       int32   `json:"tick"`
+	RunsAlive  int     `json:"runs_alive"`
+	BlackHoles float64 `json:"black_holes"`
+	WhiteHoles float64 `json:"white_holes"`
+	Wormholes  float64 `json:"wormholes"`
+	Stars      float64 `json:"stars"`
+	Asteroids  float64 `json:"asteroids"`
+}
+
+// The balance knobs in effect, so variants can be told apart
+type BatchTuning struct {
+	StandardForce          float32    `json:"standard_force"`
+	DecayRate              float32    `json:"decay_rate"`
+	AsteroidCountdownRange [2]float32 `json:"asteroid_countdown_range"`
+}
+
+type BatchStats struct {
+	Label       string           `json:"label,omitempty"`
+	Tuning      BatchTuning      `json:"tuning"`
+	Bot         string           `json:"bot"`
+	Runs        int              `json:"runs"`
+	FirstSeed   int64            `json:"first_seed"`
+	MaxTicks    int32            `json:"max_ticks"`
+	MeanScore   float64          `json:"mean_score"`
+	Survival    SurvivalStats    `json:"survival_ticks"`
+	Histogram   []SurvivalBucket `json:"survival_histogram"`
+	DeathCauses map[string]int   `json:"death_causes"`
+	Timeline    []TimelinePoint  `json:"timeline"`
+	Results     []RunResult      `json:"results"`
+}
+
+// Play every run in the batch across a pool of goroutines, each with its own headless game
+func runBatch(config BatchConfig, progress io.Writer) (BatchStats, error) {
+	if config.runs <= 0 {
+		return BatchStats{}, fmt.Errorf("a batch needs at least one run")
+	}
+	if _, err := newBot(config.bot, 0); err != nil {
+		return BatchStats{}, err
+	}
+	workers := config.workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	results := make([]RunResult, config.runs)
+	jobs := make(chan int)
+	var done sync.WaitGroup
+	var finished sync.Mutex
+	finishedRuns := 0
+	for range workers {
+		done.Add(1)
+		go func() {
+			defer done.Done()
+			for i := range jobs {
+				seed := config.firstSeed + int64(i)
+				bot, _ := newBot(config.bot, seed)
+				game := initHeadlessGame()
+				game.bots[0] = bot
+				results[i] = game.runHeadless(seed, config.maxTicks)
+
+				finished.Lock()
+				finishedRuns += 1
+				if finishedRuns%100 == 0 || finishedRuns == config.runs {
+					fmt.Fprintf(progress, "%d/%d runs\n", finishedRuns, config.runs)
+				}
+				finished.Unlock()
+			}
+		}()
+	}
+	for i := range config.runs {
+		jobs <- i
+	}
+	close(jobs)
+	done.Wait()
+
+	return summarizeBatch(config, results), nil
+}
+
+func summarizeBatch(config BatchConfig, results []RunResult) BatchStats {
+	stats := BatchStats{
+		Label: config.label,
+		Tuning: BatchTuning{
+			StandardForce:          standardForce,
+			DecayRate:              decayRate,
+			AsteroidCountdownRange: [2]float32{initialAsteroidCountdownRange.X, initialAsteroidCountdownRange.Y},
+		},
+		Bot:         config.bot,
+		Runs:        len(results),
+		FirstSeed:   config.firstSeed,
+		MaxTicks:    config.maxTicks,
+		Histogram:   []SurvivalBucket{},
+		DeathCauses: map[string]int{},
+		Timeline:    []TimelinePoint{},
+		Results:     []RunResult{},
+	}
+
+	survival := []int32{}
+	totalScore := 0.0
+	totalSurvival := 0.0
+	for _, result := range results {
+		survival = append(survival, result.SurvivalTicks)
+		totalScore += float64(result.Score)
+		totalSurvival += float64(result.SurvivalTicks)
+		stats.DeathCauses[result.DeathCause] += 1
+
+		// Per-run samples only feed the timeline
+		result.Samples = nil
+		stats.Results = append(stats.Results, result)
+	}
+	stats.MeanScore = totalScore / float64(len(results))
+
+	sort.Slice(survival, func(i, j int) bool { return survival[i] < survival[j] })
+	percentile := func(p float64) int32 {
+		return survival[int(p*float64(len(survival)-1))]
+	}
+	stats.Survival = SurvivalStats{
+		Mean:   totalSurvival / float64(len(results)),
+		Min:    survival[0],
+		P10:    percentile(0.1),
+		P25:    percentile(0.25),
+		Median: percentile(0.5),
+		P75:    percentile(0.75),
+		P90:    percentile(0.9),
+		Max:    survival[len(survival)-1],
+	}
+
+	for _, ticks := range survival {
+		bucket := int(ticks / SURVIVAL_BUCKET_TICKS)
+		for len(stats.Histogram) <= bucket {
+			from := int32(len(stats.Histogram)) * SURVIVAL_BUCKET_TICKS
+			stats.Histogram = append(stats.Histogram, SurvivalBucket{FromTicks: from, ToTicks: from + SURVIVAL_BUCKET_TICKS})
+		}
+		stats.Histogram[bucket].Runs += 1
+	}
+
+	for _, result := range results {
+		for i, sample := range result.Samples {
+			for len(stats.Timeline) <= i {
+				stats.Timeline = append(stats.Timeline, TimelinePoint{Tick: sample.Tick})
+			}
+			point := &stats.Timeline[i]
+			point.RunsAlive += 1
+			point.BlackHoles += float64(sample.BlackHoles)
+			point.WhiteHoles += float64(sample.WhiteHoles)
+			point.Wormholes += float64(sample.Wormholes)
+			point.Stars += float64(sample.Stars)
+			point.Asteroids += float64(sample.Asteroids)
+		}
+	}
+	for i := range stats.Timeline {
+		point := &stats.Timeline[i]
+		n := float64(point.RunsAlive)
+		point.BlackHoles /= n
+		point.WhiteHoles /= n
+		point.Wormholes /= n
+		point.Stars /= n
+		point.Asteroids /= n
+	}
+	return stats
+}
+
+// Write the stats as JSON to a file, or stdout for "" or "-"; a .csv path writes CSV files instead
+func (s BatchStats) write(path string) error {
+	if strings.EqualFold(filepath.Ext(path), ".csv") {
+		return s.writeCSV(path)
+	}
+	if path == "" || path == "-" {
+		return s.writeJSON(os.Stdout)
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return s.writeJSON(file)
+}
+
+func (s BatchStats) writeJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(s)
+}
+
+// One row per run in path, with the summary, histogram and timeline in sibling files
+func (s BatchStats) writeCSV(path string) error {
+	base := strings.TrimSuffix(path, filepath.Ext(path))
+	itoa := func(i int64) string { return strconv.FormatInt(i, 10) }
+	ftoa := func(f float64) string { return strconv.FormatFloat(f, 'f', 3, 64) }
+
+	runs := [][]string{{"seed", "survival_ticks", "score", "death_cause"}}
+	for _, result := range s.Results {
+		runs = append(runs, []string{itoa(result.Seed), itoa(int64(result.SurvivalTicks)), itoa(int64(result.Score)), result.DeathCause})
+	}
+
+	summary := [][]string{
+		{"metric", "value"},
+		{"label", s.Label},
+		{"standard_force", ftoa(float64(s.Tuning.StandardForce))},
+		{"decay_rate", ftoa(float64(s.Tuning.DecayRate))},
+		{"asteroid_countdown_min", ftoa(float64(s.Tuning.AsteroidCountdownRange[0]))},
+		{"asteroid_countdown_max", ftoa(float64(s.Tuning.AsteroidCountdownRange[1]))},
+		{"bot", s.Bot},
+		{"runs", itoa(int64(s.Runs))},
+		{"first_seed", itoa(s.FirstSeed)},
+		{"max_ticks", itoa(int64(s.MaxTicks))},
+		{"mean_score", ftoa(s.MeanScore)},
+		{"survival_mean", ftoa(s.Survival.Mean)},
+		{"survival_min", itoa(int64(s.Survival.Min))},
+		{"survival_p10", itoa(int64(s.Survival.P10))},
+		{"survival_p25", itoa(int64(s.Survival.P25))},
+		{"survival_median", itoa(int64(s.Survival.Median))},
+		{"survival_p75", itoa(int64(s.Survival.P75))},
+		{"survival_p90", itoa(int64(s.Survival.P90))},
+		{"survival_max", itoa(int64(s.Survival.Max))},
+	}
+	causes := []string{}
+	for cause := range s.DeathCauses {
+		causes = append(causes, cause)
+	}
+	sort.Strings(causes)
+	for _, cause := range causes {
+		summary = append(summary, []string{"deaths_" + strings.ReplaceAll(cause, " ", "_"), itoa(int64(s.DeathCauses[cause]))})
+	}
+
+	histogram := [][]string{{"from_ticks", "to_ticks", "runs"}}
+	for _, bucket := range s.Histogram {
+		histogram = append(histogram, []string{itoa(int64(bucket.FromTicks)), itoa(int64(bucket.ToTicks)), itoa(int64(bucket.Runs))})
+	}
+
+	timeline := [][]string{{"tick", "runs_alive", "black_holes", "white_holes", "wormholes", "stars", "asteroids"}}
+	for _, point := range s.Timeline {
+		timeline = append(timeline, []string{itoa(int64(point.Tick)), itoa(int64(point.RunsAlive)), ftoa(point.BlackHoles), ftoa(point.WhiteHoles), ftoa(point.Wormholes), ftoa(point.Stars), ftoa(point.Asteroids)})
+	}
+
+	files := map[string][][]string{
+		path:                   runs,
+		base + "_summary.csv":  summary,
+		base + "_survival.csv": histogram,
+		base + "_timeline.csv": timeline,
+	}
+	for name, rows := range files {
+		if err := writeCSVFile(name, rows); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeCSVFile(path string, rows [][]string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	w := csv.NewWriter(file)
+	w.WriteAll(rows)
+	return w.Error()
+}
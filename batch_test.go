@@ -0,0 +1,95 @@
+package main
+
+import (
+	"testing"
+)
+
+func survivalRuns(ticks ...int32) []RunResult {
+	results := []RunResult{}
+	for i, t := range ticks {
+		results = append(results, RunResult{Seed: int64(i), SurvivalTicks: t, Score: t / 10, DeathCause: "asteroid"})
+	}
+	return results
+}
+
+func TestSummarizeBatchSurvival(t *testing.T) {
+	// Shuffled so the percentiles have to sort
+	results := survivalRuns(700, 100, 1100, 300, 900, 500, 200, 1000, 400, 800, 600)
+	stats := summarizeBatch(BatchConfig{runs: len(results)}, results)
+
+	want := SurvivalStats{Mean: 600, Min: 100, P10: 200, P25: 300, Median: 600, P75: 800, P90: 1000, Max: 1100}
+	if stats.Survival != want {
+		t.Errorf("survival %+v, want %+v", stats.Survival, want)
+	}
+	if stats.MeanScore != 60 {
+		t.Errorf("mean score %v, want 60", stats.MeanScore)
+	}
+	if stats.Runs != len(results) || stats.DeathCauses["asteroid"] != len(results) {
+		t.Errorf("%d runs and %d asteroid deaths, want %d of each", stats.Runs, stats.DeathCauses["asteroid"], len(results))
+	}
+}
+
+func TestSummarizeBatchSingleRun(t *testing.T) {
+	stats := summarizeBatch(BatchConfig{runs: 1}, survivalRuns(450))
+	want := SurvivalStats{Mean: 450, Min: 450, P10: 450, P25: 450, Median: 450, P75: 450, P90: 450, Max: 450}
+	if stats.Survival != want {
+		t.Errorf("survival %+v, want %+v", stats.Survival, want)
+	}
+}
+
+func TestSummarizeBatchHistogram(t *testing.T) {
+	tests := []struct {
+		name  string
+		ticks []int32
+		runs  []int
+	}{
+		{"one bucket", []int32{0, 1, SURVIVAL_BUCKET_TICKS - 1}, []int{3}},
+		{"bucket edges", []int32{SURVIVAL_BUCKET_TICKS - 1, SURVIVAL_BUCKET_TICKS}, []int{1, 1}},
+		{"empty buckets in between", []int32{10, 3*SURVIVAL_BUCKET_TICKS + 10}, []int{1, 0, 0, 1}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			stats := summarizeBatch(BatchConfig{runs: len(test.ticks)}, survivalRuns(test.ticks...))
+			if len(stats.Histogram) != len(test.runs) {
+				t.Fatalf("%d buckets, want %d", len(stats.Histogram), len(test.runs))
+			}
+			for i, bucket := range stats.Histogram {
+				from := int32(i) * SURVIVAL_BUCKET_TICKS
+				if bucket.FromTicks != from || bucket.ToTicks != from+SURVIVAL_BUCKET_TICKS {
+					t.Errorf("bucket %d covers %d to %d, want %d to %d", i, bucket.FromTicks, bucket.ToTicks, from, from+SURVIVAL_BUCKET_TICKS)
+				}
+				if bucket.Runs != test.runs[i] {
+					t.Errorf("bucket %d has %d runs, want %d", i, bucket.Runs, test.runs[i])
+				}
+			}
+		})
+	}
+}
+
+func TestSummarizeBatchTimeline(t *testing.T) {
+	results := []RunResult{
+		{SurvivalTicks: 1300, Samples: []EntityCounts{{Tick: 600, BlackHoles: 2, Asteroids: 4}, {Tick: 1200, BlackHoles: 3, Asteroids: 8}}},
+		{SurvivalTicks: 700, Samples: []EntityCounts{{Tick: 600, BlackHoles: 4, Asteroids: 2}}},
+	}
+	stats := summarizeBatch(BatchConfig{runs: len(results)}, results)
+
+	want := []TimelinePoint{
+		{Tick: 600, RunsAlive: 2, BlackHoles: 3, Asteroids: 3},
+		{Tick: 1200, RunsAlive: 1, BlackHoles: 3, Asteroids: 8},
+	}
+	if len(stats.Timeline) != len(want) {
+		t.Fatalf("%d timeline points, want %d", len(stats.Timeline), len(want))
+	}
+	for i := range want {
+		if stats.Timeline[i] != want[i] {
+			t.Errorf("timeline point %d is %+v, want %+v", i, stats.Timeline[i], want[i])
+		}
+	}
+
+	// The samples are folded into the timeline rather than repeated per run
+	for i, result := range stats.Results {
+		if result.Samples != nil {
+			t.Errorf("result %d kept its samples", i)
+		}
+	}
+}
@@ -7,73 +7,217 @@ import (
 	rl "github.com/gen2brain/raylib-go/raylib"
 )
 
-const STANDARD_FORCE float32 = 2500.0
 const FUDGE_FACTOR float64 = 3.5
 const DECAYING_FORCE_ADDER float32 = 20.0
-const DECAY_RATE float32 = 0.25
 const RENDER_SCALE float32 = 3.5
+const DEATH_RADIUS_RATIO float32 = 0.4
+const BLACK_HOLE_BASE_MASS float32 = 1.0
+const RADIUS_PER_MASS float32 = 45.0
+const ASTEROID_MASS_RATIO float32 = 0.05
+const KERR_SPIN float32 = 0.6
+const FORMATION_TICKS int32 = 90
+const EVAPORATION_RADIUS float32 = 12.0
+
+// Balance knobs, which simulate can override to compare variants
+var standardForce float32 = 2500.0
+var decayRate float32 = 0.25
+
+type BlackHoleStage int
+
+const (
+	Forming BlackHoleStage = iota
+	Stable
+	Evaporating
+	FinalBurst
+)
 
 type BlackHole struct {
 	game             *Game
 	pos              rl.Vector2
 	initialRadius    float32
 	radius           float32
+	mass             float32
+	baseForce        float32 // force per unit of mass, climbs as the hole decays
 	force            float32
 	level            int
 	deathRadius      float32
 	angle            float32
 	turningDirection bool
 	rotationSpeed    float32
+	spin             float32 // strength of the frame-dragging pull, 0 for a non-rotating hole
+	formationCounter int32   // ticks left before reaching full strength
+	stage            BlackHoleStage
+	radiation        []Explosion
+	isBomb           bool // dropped by the ship, leaves no stars behind
+	feats            [MAX_PLAYERS]FeatTracker
 }
 
 func initBlackHole(g *Game, p rl.Vector2, r float32) BlackHole {
-	return BlackHole{
+	b := BlackHole{
 		game:             g,
 		pos:              p,
 		initialRadius:    r,
 		radius:           r,
-		force:            STANDARD_FORCE,
+		mass:             BLACK_HOLE_BASE_MASS,
+		baseForce:        standardForce,
+		force:            standardForce * BLACK_HOLE_BASE_MASS,
 		level:            1,
-		deathRadius:      0.4 * r,
-		angle:            rand.Float32() * 2 * math.Pi,
-		turningDirection: rand.Intn(2) > 0,
-		rotationSpeed:    rand.Float32() * math.Pi / 15,
+		angle:            g.rng.Float32() * 2 * math.Pi,
+		turningDirection: g.rng.Intn(2) > 0,
+		rotationSpeed:    g.rng.Float32() * math.Pi / 15,
+		formationCounter: FORMATION_TICKS,
+		stage:            Forming,
+		radiation:        []Explosion{},
 	}
+	b.updateDeathRadius()
+	return b
+}
+
+// A Kerr-like hole that drags objects around in the direction it spins
+func initRotatingBlackHole(g *Game, p rl.Vector2, r float32) BlackHole {
+	b := initBlackHole(g, p, r)
+	b.rotationSpeed = math.Pi / 10
+	b.spin = KERR_SPIN
+	if !b.turningDirection {
+		b.spin = -KERR_SPIN
+	}
+	return b
 }
 
 func (b *BlackHole) render() {
-	scale := b.radius / b.initialRadius
-	t := b.game.blackHoleTexture
-	scaledTWidth := RENDER_SCALE * float32(t.Width) * scale
-	scaledTHeight := RENDER_SCALE * float32(t.Height) * scale
-	rl.DrawTexturePro(
-		t,
-		rl.NewRectangle(0, 0, float32(t.Width), float32(t.Height)),
+	scale := b.radius / b.initialRadius * (0.3 + 0.7*b.formationScale())
+	sprite := b.game.blackHoleSprite
+	scaledTWidth := RENDER_SCALE * sprite.width() * scale
+	scaledTHeight := RENDER_SCALE * sprite.height() * scale
+	sprite.draw(
 		rl.NewRectangle(b.pos.X, b.pos.Y, scaledTWidth, scaledTHeight),
 		rl.Vector2{
 			X: scaledTWidth / 2,
 			Y: scaledTHeight / 2,
 		},
 		b.angle*(180/math.Pi),
-		rl.White,
+		b.tint(),
 	)
+
+	switch b.stage {
+	case Forming:
+		// Swirl of infalling matter
+		rl.DrawCircleLines(int32(b.pos.X), int32(b.pos.Y), b.radius*(2-b.formationScale()), rl.Fade(rl.Purple, 1-b.formationScale()))
+	case Evaporating:
+		// Hawking radiation
+		for _, particle := range b.radiation {
+			particle.render()
+		}
+		pulse := float32(math.Abs(math.Sin(float64(b.radius) * math.Pi)))
+		rl.DrawCircleLines(int32(b.pos.X), int32(b.pos.Y), b.radius*RENDER_SCALE*0.5, rl.Fade(rl.Violet, pulse))
+	}
+}
+
+func (b *BlackHole) tint() rl.Color {
+	if b.stage == Evaporating {
+		// Flicker as the hole runs out
+		return rl.Fade(rl.White, 0.5+0.5*float32(math.Abs(math.Sin(float64(b.radius)*2*math.Pi))))
+	}
+	if b.spin != 0 {
+		return rl.SkyBlue
+	}
+	return rl.White
 }
 
 func (b *BlackHole) update() {
-	b.radius -= DECAY_RATE
-	b.deathRadius -= 0.4 * DECAY_RATE
-	b.force += DECAYING_FORCE_ADDER
+	b.radius -= decayRate
+	b.formationCounter = max(0, b.formationCounter-1)
+	b.updateDeathRadius()
+	b.baseForce += DECAYING_FORCE_ADDER
+	b.updateForce()
 	if b.turningDirection {
 		b.angle += b.rotationSpeed
 	} else {
 		b.angle -= b.rotationSpeed
 	}
+
+	switch {
+	case b.radius <= decayRate:
+		b.stage = FinalBurst
+	case b.formationCounter > 0:
+		b.stage = Forming
+	case b.radius < EVAPORATION_RADIUS:
+		b.stage = Evaporating
+	default:
+		b.stage = Stable
+	}
+
+	// Emit radiation while evaporating
+	newRadiation := []Explosion{}
+	for _, particle := range b.radiation {
+		particle.update()
+		if particle.speed > 0 {
+			newRadiation = append(newRadiation, particle)
+		}
+	}
+	if b.stage == Evaporating {
+		newRadiation = append(newRadiation, initExplosion(nil, b.pos, rand.Float32()*2*math.Pi, rand.Float32()*2+1, hawkingColorChoices))
+	}
+	b.radiation = newRadiation
+}
+
+// Fraction of full strength, ramping up from 0 while the hole forms
+func (b *BlackHole) formationScale() float32 {
+	return 1 - float32(b.formationCounter)/float32(FORMATION_TICKS)
+}
+
+// The core only becomes deadly as the hole finishes forming
+func (b *BlackHole) updateDeathRadius() {
+	b.deathRadius = DEATH_RADIUS_RATIO * b.radius * b.formationScale()
+}
+
+// Pull grows with mass; a bomb pulls more gently so the ship that dropped it can fly clear
+func (b *BlackHole) updateForce() {
+	b.force = b.baseForce * b.mass
+	if b.isBomb {
+		b.force *= GRAVITY_BOMB_FORCE_SCALE
+	}
 }
 
 func (b *BlackHole) calculateForceOnObject(obj rl.Vector2) rl.Vector2 {
 	angle := math.Atan2(float64(b.pos.Y-obj.Y), float64(b.pos.X-obj.X))
 	dis := math.Sqrt(math.Pow(float64(b.pos.Y-obj.Y), 2) + math.Pow(float64(b.pos.X-obj.X), 2))
 
-	gForce := float64(b.force) / (FUDGE_FACTOR * math.Pow(dis, 2))
-	return rl.Vector2{X: float32(math.Cos(angle) * gForce), Y: float32(math.Sin(angle) * gForce)}
+	gForce := float64(b.force*b.formationScale()) / (FUDGE_FACTOR * math.Pow(dis, 2))
+	force := rl.Vector2{X: float32(math.Cos(angle) * gForce), Y: float32(math.Sin(angle) * gForce)}
+
+	// Frame dragging pulls sideways, in the direction of rotation
+	if b.spin != 0 {
+		dragForce := float64(b.spin) * gForce
+		force = rl.Vector2Add(force, rl.Vector2{
+			X: float32(math.Cos(angle-math.Pi/2) * dragForce),
+			Y: float32(math.Sin(angle-math.Pi/2) * dragForce),
+		})
+	}
+	return force
+}
+
+// Swallow matter, growing the hole and strengthening its pull
+func (b *BlackHole) consume(m float32) {
+	b.mass += m
+	b.radius += m * RADIUS_PER_MASS
+	b.updateDeathRadius()
+	b.updateForce()
+}
+
+// Combine another black hole into this one
+func (b *BlackHole) merge(o BlackHole) {
+	totalMass := b.mass + o.mass
+	b.pos = rl.Vector2Scale(rl.Vector2Add(rl.Vector2Scale(b.pos, b.mass), rl.Vector2Scale(o.pos, o.mass)), 1/totalMass)
+	b.spin = (b.spin*b.mass + o.spin*o.mass) / totalMass
+	b.mass = totalMass
+
+	// Keep the combined event horizon area
+	b.radius = float32(math.Sqrt(float64(b.radius*b.radius + o.radius*o.radius)))
+	b.initialRadius = max(b.initialRadius, o.initialRadius)
+	b.updateDeathRadius()
+	b.baseForce = max(b.baseForce, o.baseForce)
+	b.isBomb = b.isBomb && o.isBomb
+	b.updateForce()
+	b.level = max(b.level, o.level) + 1
 }
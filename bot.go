@@ -0,0 +1,252 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+const BOT_LOOKAHEAD_TICKS float32 = 60
+const BOT_SAFE_DISTANCE float32 = 70
+const BOT_EDGE_MARGIN float32 = 160
+const BOT_PULL_WEIGHT float32 = 20
+const BOT_STAR_DANGER_TICKS int32 = 90
+const BOT_STAR_DANGER_RADIUS float32 = 220
+const BOT_PANIC_TICKS float32 = 12
+
+// What a bot can see of the ship it flies
+type ShipObservation struct {
+	Pos         rl.Vector2 `json:"pos"`
+	Velocity    rl.Vector2 `json:"velocity"`
+	Angle       float32    `json:"angle"`
+	EngineSpeed float64    `json:"engine_speed"`
+	Fuel        float32    `json:"fuel"`
+	IsDead      bool       `json:"is_dead"`
+}
+
+type BlackHoleObservation struct {
+	Pos         rl.Vector2 `json:"pos"`
+	Radius      float32    `json:"radius"`
+	Force       float32    `json:"force"`
+	DeathRadius float32    `json:"death_radius"`
+}
+
+type StarObservation struct {
+	Pos             rl.Vector2 `json:"pos"`
+	DetonationTicks int32      `json:"detonation_ticks"`
+}
+
+type AsteroidObservation struct {
+	Pos      rl.Vector2 `json:"pos"`
+	Velocity rl.Vector2 `json:"velocity"`
+	Radius   float32    `json:"radius"`
+}
+
+// Everything a bot is told about the game on one tick
+type Observation struct {
+	Tick       int32                  `json:"tick"`
+	Score      int32                  `json:"score"`
+	Width      float32                `json:"width"` // of the play field
+	Height     float32                `json:"height"`
+	Ship       ShipObservation        `json:"ship"`
+	BlackHoles []BlackHoleObservation `json:"black_holes"`
+	Stars      []StarObservation      `json:"stars"`
+	Asteroids  []AsteroidObservation  `json:"asteroids"`
+}
+
+// Anything that can fly a ship in place of the keyboard
+type Bot interface {
+	act(o Observation) ShipControls
+}
+
+// Names accepted by newBot
+var botNames = []string{"heuristic", "random"}
+
+func newBot(name string, seed int64) (Bot, error) {
+	switch name {
+	case "heuristic":
+		return &HeuristicBot{}, nil
+	case "random":
+		return &RandomBot{rng: rand.New(rand.NewSource(seed))}, nil
+	}
+	return nil, fmt.Errorf("unknown bot %q (choose from %v)", name, botNames)
+}
+
+// The game as seen from one player's ship
+func (g *Game) observe(player int) Observation {
+	ship := &g.ships[player]
+	score := g.score
+	if g.mode == Versus {
+		score = ship.score
+	}
+
+	o := Observation{
+		Tick:   g.elapsedTicks,
+		Score:  score,
+		Width:  float32(g.width),
+		Height: float32(g.height),
+		Ship: ShipObservation{
+			Pos:         ship.pos,
+			Velocity:    ship.currentVelocity(),
+			Angle:       ship.angle,
+			EngineSpeed: ship.engineSpeed,
+			Fuel:        ship.fuel,
+			IsDead:      ship.isDead,
+		},
+		BlackHoles: []BlackHoleObservation{},
+		Stars:      []StarObservation{},
+		Asteroids:  []AsteroidObservation{},
+	}
+	for _, blackHole := range g.blackHoleList {
+		o.BlackHoles = append(o.BlackHoles, BlackHoleObservation{Pos: blackHole.pos, Radius: blackHole.radius, Force: blackHole.force, DeathRadius: blackHole.deathRadius})
+	}
+	for _, star := range g.starList {
+		o.Stars = append(o.Stars, StarObservation{Pos: star.pos, DetonationTicks: star.detonationCounter})
+	}
+	for _, asteroid := range g.asteroidList {
+		if asteroid.isAlive {
+			o.Asteroids = append(o.Asteroids, AsteroidObservation{Pos: asteroid.pos, Velocity: asteroid.velocity, Radius: asteroid.radius})
+		}
+	}
+	return o
+}
+
+// Controls for a player this tick, from their bot if they have one, otherwise the keyboard and gamepad
+func (g *Game) playerControls(player int) ShipControls {
+	if g.bots[player] != nil {
+		return g.bots[player].act(g.observe(player))
+	}
+	if g.headless {
+		return ShipControls{}
+	}
+	gamepad := int32(player)
+	if g.net != nil {
+		gamepad = 0
+	}
+	return addGamepadControls(readKeyboardControls(g.keyBindings(player)), gamepad)
+}
+
+// Steers away from the sum of everything dangerous: black hole pull, incoming asteroids, ripe stars and the screen edges
+type HeuristicBot struct{}
+
+func (b *HeuristicBot) act(o Observation) ShipControls {
+	ship := o.Ship
+	push := rl.Vector2{}
+	imminent := false
+
+	// Lean away from black holes in proportion to their pull
+	for _, blackHole := range o.BlackHoles {
+		away := rl.Vector2Subtract(ship.Pos, blackHole.Pos)
+		dis := max(1, rl.Vector2Length(away)-blackHole.DeathRadius)
+		pull := blackHole.Force / float32(FUDGE_FACTOR*float64(dis*dis))
+		push = rl.Vector2Add(push, rl.Vector2Scale(rl.Vector2Normalize(away), pull*BOT_PULL_WEIGHT))
+	}
+
+	// Dodge asteroids whose closest approach comes too close, the sooner the harder
+	for _, asteroid := range o.Asteroids {
+		offset := rl.Vector2Subtract(asteroid.Pos, ship.Pos)
+		relative := rl.Vector2Subtract(asteroid.Velocity, ship.Velocity)
+		speedSquared := rl.Vector2LengthSqr(relative)
+		t := float32(0)
+		if speedSquared > 0 {
+			t = max(0, -rl.Vector2DotProduct(offset, relative)/speedSquared)
+		}
+		if t > BOT_LOOKAHEAD_TICKS {
+			continue
+		}
+		closest := rl.Vector2Add(offset, rl.Vector2Scale(relative, t))
+		if rl.Vector2Length(closest) > BOT_SAFE_DISTANCE+asteroid.Radius {
+			continue
+		}
+		away := rl.Vector2Negate(closest)
+		if rl.Vector2Length(away) == 0 {
+			// Dead centre: sidestep at right angles to its path
+			away = rl.Vector2{X: -relative.Y, Y: relative.X}
+		}
+		urgency := 1 - t/BOT_LOOKAHEAD_TICKS
+		push = rl.Vector2Add(push, rl.Vector2Scale(rl.Vector2Normalize(away), 2*urgency))
+		if t < BOT_PANIC_TICKS {
+			imminent = true
+		}
+	}
+
+	// Keep clear of stars about to go supernova
+	for _, star := range o.Stars {
+		away := rl.Vector2Subtract(ship.Pos, star.Pos)
+		dis := rl.Vector2Length(away)
+		if star.DetonationTicks < BOT_STAR_DANGER_TICKS && dis < BOT_STAR_DANGER_RADIUS {
+			push = rl.Vector2Add(push, rl.Vector2Scale(rl.Vector2Normalize(away), 1-dis/BOT_STAR_DANGER_RADIUS))
+		}
+	}
+
+	// Stay off the edges
+	edgeDistances := []struct {
+		dis       float32
+		direction rl.Vector2
+	}{
+		{ship.Pos.X, rl.Vector2{X: 1}},
+		{o.Width - ship.Pos.X, rl.Vector2{X: -1}},
+		{ship.Pos.Y, rl.Vector2{Y: 1}},
+		{o.Height - ship.Pos.Y, rl.Vector2{Y: -1}},
+	}
+	for _, edge := range edgeDistances {
+		if edge.dis < BOT_EDGE_MARGIN {
+			closeness := 1 - max(0, edge.dis)/BOT_EDGE_MARGIN
+			push = rl.Vector2Add(push, rl.Vector2Scale(edge.direction, 2*closeness*closeness))
+		}
+	}
+
+	// With nothing to flee, drift back towards the middle
+	urgency := rl.Vector2Length(push)
+	if urgency < 0.2 {
+		center := rl.Vector2{X: o.Width / 2, Y: o.Height / 2}
+		push = rl.Vector2Add(push, rl.Vector2Scale(rl.Vector2Normalize(rl.Vector2Subtract(center, ship.Pos)), 0.2))
+	}
+
+	// Turn to face the way we want to go, throttling up only once roughly lined up
+	controls := ShipControls{}
+	heading := math.Atan2(float64(push.Y), float64(push.X))
+	diff := float32(math.Remainder(heading-float64(ship.Angle), 2*math.Pi))
+	controls.turn = max(-1, min(1, diff/0.2))
+	targetSpeed := 1.5 + 3*math.Min(1, float64(urgency))
+	aligned := math.Abs(float64(diff)) < math.Pi/3
+	speed := float64(rl.Vector2Length(ship.Velocity))
+	if aligned && speed < targetSpeed {
+		controls.throttle = 1
+	} else if !aligned || speed > targetSpeed {
+		controls.throttle = -1
+	}
+	if imminent {
+		for i := range controls.abilities {
+			controls.abilities[i] = true
+		}
+	}
+	return controls
+}
+
+// Mashes random controls, holding each choice for a short while
+type RandomBot struct {
+	rng       *rand.Rand
+	current   ShipControls
+	holdTicks int32
+}
+
+func (b *RandomBot) act(o Observation) ShipControls {
+	b.holdTicks -= 1
+	if b.holdTicks <= 0 {
+		b.current = ShipControls{
+			turn:     float32(b.rng.Intn(3) - 1),
+			throttle: float32(b.rng.Intn(3) - 1),
+			strafe:   float32(b.rng.Intn(3) - 1),
+		}
+		b.holdTicks = int32(b.rng.Intn(30)) + 10
+	}
+
+	controls := b.current
+	for i := range controls.abilities {
+		controls.abilities[i] = b.rng.Intn(300) == 0
+	}
+	return controls
+}
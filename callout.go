@@ -0,0 +1,42 @@
+package main
+
+import (
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+const CALLOUT_LIFETIME int32 = 75
+const CALLOUT_RISE_SPEED float32 = 0.6
+
+// Floating text announcing points or a feat
+type Callout struct {
+	pos      rl.Vector2
+	text     string
+	color    rl.Color
+	size     int32
+	lifetime int32
+}
+
+func initCallout(p rl.Vector2, text string, c rl.Color, size int32) Callout {
+	return Callout{
+		pos:      p,
+		text:     text,
+		color:    c,
+		size:     size,
+		lifetime: CALLOUT_LIFETIME,
+	}
+}
+
+func (c *Callout) render() {
+	alpha := min(1, float32(c.lifetime)/30)
+	width := rl.MeasureText(c.text, c.size)
+	rl.DrawText(c.text, int32(c.pos.X)-width/2, int32(c.pos.Y), c.size, rl.Fade(c.color, alpha))
+}
+
+func (c *Callout) update() {
+	c.pos.Y -= CALLOUT_RISE_SPEED
+	c.lifetime -= 1
+}
+
+func (g *Game) addCallout(p rl.Vector2, text string, c rl.Color) {
+	g.calloutList = append(g.calloutList, initCallout(p, text, c, 28))
+}
@@ -0,0 +1,414 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Set at build time with -ldflags "-X main.version=..."
+var version = "dev"
+
+const MIN_WINDOW_WIDTH int = 800
+const MIN_WINDOW_HEIGHT int = 600
+const MAX_WINDOW_WIDTH int = 7680
+const MAX_WINDOW_HEIGHT int = 4320
+
+const usage = `Black Hole Bounce
+
+Usage:
+  black-hole-bounce [play] [flags]     play in a window (the default)
+  black-hole-bounce replay <file>      watch a recorded run
+  black-hole-bounce verify <file>      re-simulate a replay and check it ends as recorded
+  black-hole-bounce simulate [flags]   play many headless games with a bot and write statistics
+  black-hole-bounce gym [endpoint]     serve the training environment on stdio or tcp:ADDRESS
+  black-hole-bounce --version          print the version
+
+Run a command with -h for its flags.
+`
+
+// Settings for a windowed game; a -config file supplies defaults that flags override
+type PlayConfig struct {
+	Seed       int64   `json:"seed"` // 0 seeds each run from the clock
+	Mode       string  `json:"mode"`
+	Difficulty string  `json:"difficulty"`
+	Flight     string  `json:"flight"`
+	Width      int     `json:"width"`
+	Height     int     `json:"height"`
+	Fullscreen bool    `json:"fullscreen"`
+	Bot        string  `json:"bot"`
+	Host       string  `json:"host"`
+	Join       string  `json:"join"`
+	Record     string  `json:"record"`
+	Assets     string  `json:"assets"`
+	Watch      bool    `json:"watch"`
+	Music      float64 `json:"music_volume"` // -1 keeps the saved setting
+}
+
+var defaultPlayConfig = PlayConfig{
+	Mode:       "solo",
+	Difficulty: "normal",
+	Flight:     "arcade",
+	Width:      DEFAULT_WINDOW_WIDTH,
+	Height:     DEFAULT_WINDOW_HEIGHT,
+	Host:       DEFAULT_HOST_ADDRESS,
+	Join:       DEFAULT_JOIN_ADDRESS,
+	Music:      -1,
+}
+
+// Run the command line, returning the process exit code
+func runCLI(args []string, stdout io.Writer, stderr io.Writer) int {
+	command := "play"
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		command, args = args[0], args[1:]
+	}
+
+	var err error
+	switch command {
+	case "play":
+		err = playCommand(args, stdout, stderr)
+	case "replay":
+		err = replayCommand(args, stderr)
+	case "verify":
+		err = verifyCommand(args, stdout, stderr)
+	case "simulate":
+		err = simulateCommand(args, stderr)
+	case "gym":
+		err = gymCommand(args, stderr)
+	case "version":
+		fmt.Fprintf(stdout, "black-hole-bounce %s\n", version)
+	case "help":
+		fmt.Fprint(stdout, usage)
+	default:
+		err = usageError{fmt.Errorf("unknown command %q", command)}
+	}
+
+	var badUsage usageError
+	switch {
+	case err == nil:
+		return 0
+	case errors.Is(err, flag.ErrHelp):
+		return 0
+	case errors.As(err, &badUsage):
+		fmt.Fprintf(stderr, "error: %v\n\n%s", err, usage)
+		return 2
+	default:
+		fmt.Fprintf(stderr, "error: %v\n", err)
+		return 1
+	}
+}
+
+// A mistake in how the command was invoked, as opposed to a failure while running it
+type usageError struct {
+	err error
+}
+
+func (e usageError) Error() string {
+	return e.err.Error()
+}
+
+func newFlagSet(name string, stderr io.Writer, summary string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, "%s\n\nFlags:\n", summary)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// Parse flags, which must come before any positional arguments, turning mistakes into usage errors
+func parseFlags(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		return usageError{err}
+	}
+	return nil
+}
+
+func playCommand(args []string, stdout io.Writer, stderr io.Writer) error {
+	fs := newFlagSet("play", stderr, "Usage: black-hole-bounce play [flags]")
+	flags := defaultPlayConfig
+	configPath := fs.String("config", "", "JSON file of play settings; flags given alongside it win")
+	showVersion := fs.Bool("version", false, "print the version and exit")
+	fs.Int64Var(&flags.Seed, "seed", flags.Seed, "seed every run with this value (0 for a new seed each run)")
+	fs.StringVar(&flags.Mode, "mode", flags.Mode, "game mode: solo, coop or versus")
+	fs.StringVar(&flags.Difficulty, "difficulty", flags.Difficulty, "difficulty: easy, normal or hard")
+	fs.StringVar(&flags.Flight, "flight", flags.Flight, "flight model: arcade or newtonian")
+	fs.IntVar(&flags.Width, "width", flags.Width, "window width in pixels")
+	fs.IntVar(&flags.Height, "height", flags.Height, "window height in pixels")
+	fs.BoolVar(&flags.Fullscreen, "fullscreen", flags.Fullscreen, "start fullscreen")
+	fs.StringVar(&flags.Bot, "bot", flags.Bot, fmt.Sprintf("let a bot fly player 1: one of %v", botNames))
+	fs.StringVar(&flags.Host, "host", flags.Host, "address to listen on when hosting a network game")
+	fs.StringVar(&flags.Join, "join", flags.Join, "address of the host when joining a network game")
+	fs.StringVar(&flags.Record, "record", flags.Record, "save a replay of each run to this file")
+	fs.StringVar(&flags.Assets, "assets", flags.Assets, "directory of images and sounds to use instead of the built-in ones")
+	fs.BoolVar(&flags.Watch, "watch", flags.Watch, "reload files in the -assets directory as they change")
+	fs.Float64Var(&flags.Music, "music-volume", flags.Music, "music volume from 0 to 1 for this session (-1 keeps the saved setting)")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *showVersion {
+		fmt.Fprintf(stdout, "black-hole-bounce %s\n", version)
+		return nil
+	}
+	if fs.NArg() > 0 {
+		return usageError{fmt.Errorf("play takes no arguments, got %q", fs.Args())}
+	}
+
+	config := defaultPlayConfig
+	if *configPath != "" {
+		if err := loadPlayConfig(*configPath, &config); err != nil {
+			return err
+		}
+	}
+	// Only the flags actually given override the config file
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "seed":
+			config.Seed = flags.Seed
+		case "mode":
+			config.Mode = flags.Mode
+		case "difficulty":
+			config.Difficulty = flags.Difficulty
+		case "flight":
+			config.Flight = flags.Flight
+		case "width":
+			config.Width = flags.Width
+		case "height":
+			config.Height = flags.Height
+		case "fullscreen":
+			config.Fullscreen = flags.Fullscreen
+		case "bot":
+			config.Bot = flags.Bot
+		case "host":
+			config.Host = flags.Host
+		case "join":
+			config.Join = flags.Join
+		case "record":
+			config.Record = flags.Record
+		case "assets":
+			config.Assets = flags.Assets
+		case "watch":
+			config.Watch = flags.Watch
+		case "music-volume":
+			config.Music = flags.Music
+		}
+	})
+
+	mode, err := parseGameMode(config.Mode)
+	if err != nil {
+		return usageError{err}
+	}
+	difficulty, err := parseDifficulty(config.Difficulty)
+	if err != nil {
+		return usageError{err}
+	}
+	flightModel, err := parseFlightModel(config.Flight)
+	if err != nil {
+		return usageError{err}
+	}
+	if err := checkWindowSize(config.Width, config.Height); err != nil {
+		return usageError{err}
+	}
+	var bot Bot
+	if config.Bot != "" {
+		if bot, err = newBot(config.Bot, config.Seed); err != nil {
+			return usageError{err}
+		}
+	}
+
+	if config.Music != -1 && (config.Music < 0 || config.Music > 1) {
+		return usageError{fmt.Errorf("music volume must be from 0 to 1, got %g", config.Music)}
+	}
+	if config.Watch && config.Assets == "" {
+		return usageError{fmt.Errorf("-watch needs an -assets directory to watch")}
+	}
+
+	game := initGame(WindowConfig{
+		width:       config.Width,
+		height:      config.Height,
+		fullscreen:  config.Fullscreen,
+		assetDir:    config.Assets,
+		watchAssets: config.Watch,
+	})
+	game.mode = mode
+	game.difficulty = difficulty
+	game.flightModel = flightModel
+	game.fixedSeed = config.Seed
+	game.hostAddress = config.Host
+	game.joinAddress = config.Join
+	game.recordPath = config.Record
+	game.bots[0] = bot
+	if config.Music >= 0 {
+		game.mixer.setVolume(MusicBus, float32(config.Music))
+	}
+	game.run()
+	return nil
+}
+
+func loadPlayConfig(path string, config *PlayConfig) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(config); err != nil {
+		return fmt.Errorf("reading config %s: %v", path, err)
+	}
+	return nil
+}
+
+// The single replay file a command expects
+func replayArgument(fs *flag.FlagSet, command string) (*Replay, error) {
+	if fs.NArg() != 1 {
+		return nil, usageError{fmt.Errorf("%s needs exactly one replay file", command)}
+	}
+	return loadReplay(fs.Arg(0))
+}
+
+func replayCommand(args []string, stderr io.Writer) error {
+	fs := newFlagSet("replay", stderr, "Usage: black-hole-bounce replay [flags] <file>")
+	fullscreen := fs.Bool("fullscreen", false, "watch fullscreen")
+	assets := fs.String("assets", "", "directory of images and sounds to use instead of the built-in ones")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	replay, err := replayArgument(fs, "replay")
+	if err != nil {
+		return err
+	}
+
+	game := initGame(WindowConfig{
+		width:      replay.Setup.Width,
+		height:     replay.Setup.Height,
+		fullscreen: *fullscreen,
+		assetDir:   *assets,
+	})
+	game.startPlayback(replay)
+	game.run()
+	return nil
+}
+
+func verifyCommand(args []string, stdout io.Writer, stderr io.Writer) error {
+	fs := newFlagSet("verify", stderr, "Usage: black-hole-bounce verify <file>")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	replay, err := replayArgument(fs, "verify")
+	if err != nil {
+		return err
+	}
+
+	if err := verifyReplay(replay); err != nil {
+		return fmt.Errorf("%s does not match: %v", fs.Arg(0), err)
+	}
+	fmt.Fprintf(stdout, "%s: ok, %d ticks, score %d\n", fs.Arg(0), replay.Ticks, replay.Score)
+	return nil
+}
+
+func simulateCommand(args []string, stderr io.Writer) error {
+	fs := newFlagSet("simulate", stderr, "Usage: black-hole-bounce simulate [flags]")
+	config := BatchConfig{}
+	fs.StringVar(&config.bot, "bot", "heuristic", fmt.Sprintf("bot to fly every run: one of %v", botNames))
+	fs.IntVar(&config.runs, "runs", 100, "number of games to play")
+	fs.Int64Var(&config.firstSeed, "seed", 1, "seed of the first run; run i uses seed+i")
+	fs.IntVar(&config.workers, "workers", 0, "goroutines to spread the runs over (0 for one per CPU)")
+	ticks := fs.Int("ticks", 0, "stop each run after this many ticks (0 for no limit)")
+	fs.StringVar(&config.label, "label", "", "name for the tuning variant in the statistics")
+	out := fs.String("out", "", "where to write statistics: a .json or .csv path, or stdout if empty")
+	force := fs.Float64("force", float64(standardForce), "pull of a new black hole")
+	decay := fs.Float64("decay", float64(decayRate), "radius black and white holes lose each tick")
+	countdownMin := fs.Int("countdown-min", int(initialAsteroidCountdownRange.X), "fewest ticks between the first asteroid waves")
+	countdownMax := fs.Int("countdown-max", int(initialAsteroidCountdownRange.Y), "most ticks between the first asteroid waves")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return usageError{fmt.Errorf("simulate takes no arguments, got %q", fs.Args())}
+	}
+	if *force <= 0 || *decay <= 0 {
+		return usageError{fmt.Errorf("-force and -decay must be positive")}
+	}
+	if *countdownMin <= 0 || *countdownMax <= *countdownMin {
+		return usageError{fmt.Errorf("-countdown-min must be positive and below -countdown-max, got %d and %d", *countdownMin, *countdownMax)}
+	}
+	if config.runs <= 0 {
+		return usageError{fmt.Errorf("-runs must be positive, got %d", config.runs)}
+	}
+	if config.workers < 0 || *ticks < 0 {
+		return usageError{fmt.Errorf("-workers and -ticks can't be negative")}
+	}
+	if _, err := newBot(config.bot, 0); err != nil {
+		return usageError{err}
+	}
+	config.maxTicks = int32(*ticks)
+	standardForce = float32(*force)
+	decayRate = float32(*decay)
+	initialAsteroidCountdownRange.X = float32(*countdownMin)
+	initialAsteroidCountdownRange.Y = float32(*countdownMax)
+
+	stats, err := runBatch(config, stderr)
+	if err != nil {
+		return err
+	}
+	return stats.write(*out)
+}
+
+func gymCommand(args []string, stderr io.Writer) error {
+	fs := newFlagSet("gym", stderr, "Usage: black-hole-bounce gym [stdio | tcp:ADDRESS]")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	endpoint := "stdio"
+	switch fs.NArg() {
+	case 0:
+	case 1:
+		endpoint = fs.Arg(0)
+	default:
+		return usageError{fmt.Errorf("gym takes at most one endpoint, got %q", fs.Args())}
+	}
+	return runGym(endpoint)
+}
+
+func checkWindowSize(width int, height int) error {
+	if width < MIN_WINDOW_WIDTH || height < MIN_WINDOW_HEIGHT || width > MAX_WINDOW_WIDTH || height > MAX_WINDOW_HEIGHT {
+		return fmt.Errorf("window must be from %dx%d to %dx%d, got %dx%d", MIN_WINDOW_WIDTH, MIN_WINDOW_HEIGHT, MAX_WINDOW_WIDTH, MAX_WINDOW_HEIGHT, width, height)
+	}
+	return nil
+}
+
+func parseGameMode(s string) (GameMode, error) {
+	for _, mode := range []GameMode{Solo, Coop, Versus} {
+		if strings.EqualFold(strings.ReplaceAll(mode.name(), "-", ""), strings.ReplaceAll(s, "-", "")) {
+			return mode, nil
+		}
+	}
+	return Solo, fmt.Errorf("unknown mode %q (choose solo, coop or versus)", s)
+}
+
+func parseDifficulty(s string) (Difficulty, error) {
+	for i, settings := range difficultySettings {
+		if strings.EqualFold(settings.name, s) {
+			return Difficulty(i), nil
+		}
+	}
+	return Normal, fmt.Errorf("unknown difficulty %q (choose easy, normal or hard)", s)
+}
+
+func parseFlightModel(s string) (FlightModel, error) {
+	for _, f := range []FlightModel{Arcade, Newtonian} {
+		if strings.EqualFold(f.name(), s) {
+			return f, nil
+		}
+	}
+	return Arcade, fmt.Errorf("unknown flight model %q (choose arcade or newtonian)", s)
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"math"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+const DEBRIS_LIFETIME int32 = 600
+const DEBRIS_PICKUP_RADIUS float32 = 14
+const DEBRIS_FUEL float32 = 12
+
+// Fuel-bearing debris left behind by a destroyed asteroid
+type Debris struct {
+	game     *Game
+	pos      rl.Vector2
+	velocity rl.Vector2
+	fuel     float32
+	lifetime int32
+}
+
+func initDebris(g *Game, p rl.Vector2, v rl.Vector2, f float32) Debris {
+	return Debris{
+		game:     g,
+		pos:      p,
+		velocity: v,
+		fuel:     f,
+		lifetime: DEBRIS_LIFETIME,
+	}
+}
+
+func (d *Debris) render() {
+	// Blink out over the last couple of seconds
+	if d.lifetime < 120 && (d.lifetime/8)%2 == 0 {
+		return
+	}
+	rl.DrawPoly(d.pos, 4, 5, float32(d.lifetime)*3, rl.Lime)
+	rl.DrawPolyLines(d.pos, 4, 7, float32(d.lifetime)*3, rl.Fade(rl.Green, 0.6))
+}
+
+func (d *Debris) update() {
+	d.lifetime -= 1
+	d.velocity = rl.Vector2Add(d.velocity, d.game.calculateFieldForce(d.pos))
+	d.velocity = rl.Vector2Scale(d.velocity, 0.98)
+	d.pos = rl.Vector2Add(d.pos, d.velocity)
+
+	// Swallowed by a black hole
+	for _, b := range d.game.blackHoleList {
+		if rl.CheckCollisionCircles(d.pos, 1, b.pos, b.deathRadius) {
+			d.lifetime = 0
+			return
+		}
+	}
+
+	// Picked up by a ship
+	for i := range d.game.ships {
+		s := &d.game.ships[i]
+		if !s.isDead && rl.CheckCollisionCircles(d.pos, DEBRIS_PICKUP_RADIUS, s.pos, s.radius) {
+			s.refuel(d.fuel)
+			d.lifetime = 0
+			return
+		}
+	}
+}
+
+func (d *Debris) isAlive() bool {
+	return d.lifetime > 0 && d.game.isOnScreen(d.pos)
+}
+
+// Scatter fuel debris from a destroyed asteroid
+func (g *Game) dropDebris(p rl.Vector2, v rl.Vector2, count int) {
+	for range count {
+		angle := g.rng.Float64() * 2 * math.Pi
+		velocity := rl.Vector2Add(rl.Vector2Scale(v, 0.3), headingVelocity(angle, 1+g.rng.Float32()*2))
+		g.debrisList = append(g.debrisList, initDebris(g, p, velocity, DEBRIS_FUEL))
+	}
+}
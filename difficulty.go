@@ -0,0 +1,42 @@
+package main
+
+type Difficulty int
+
+const (
+	Easy Difficulty = iota
+	Normal
+	Hard
+)
+
+type DifficultySettings struct {
+	name            string
+	showTrajectory  bool // whether the predicted path starts switched on
+	trajectoryTicks int  // how far ahead the predicted path looks
+}
+
+// Indexed by Difficulty
+var difficultySettings = []DifficultySettings{
+	Easy: {
+		name:            "Easy",
+		showTrajectory:  true,
+		trajectoryTicks: 120,
+	},
+	Normal: {
+		name:            "Normal",
+		showTrajectory:  true,
+		trajectoryTicks: 60,
+	},
+	Hard: {
+		name:            "Hard",
+		showTrajectory:  false,
+		trajectoryTicks: 45,
+	},
+}
+
+func (d Difficulty) settings() DifficultySettings {
+	return difficultySettings[d]
+}
+
+func (d Difficulty) next() Difficulty {
+	return (d + 1) % Difficulty(len(difficultySettings))
+}
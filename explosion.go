@@ -8,6 +8,7 @@ import (
 )
 
 var colorChoices []rl.Color = []rl.Color{rl.Red, rl.Orange, rl.Yellow, rl.Gold}
+var hawkingColorChoices []rl.Color = []rl.Color{rl.White, rl.Violet, rl.SkyBlue, rl.Purple}
 
 type Explosion struct {
 	cluster *ExplosionCluster
@@ -17,13 +18,13 @@ type Explosion struct {
 	color   rl.Color
 }
 
-func initExplosion(c *ExplosionCluster, p rl.Vector2, a float32, s float32) Explosion {
+func initExplosion(c *ExplosionCluster, p rl.Vector2, a float32, s float32, colors []rl.Color) Explosion {
 	return Explosion{
 		cluster: c,
 		pos:     p,
 		angle:   a,
 		speed:   s,
-		color:   colorChoices[rand.Intn(len(colorChoices))],
+		color:   colors[rand.Intn(len(colors))],
 	}
 }
 
@@ -14,6 +14,10 @@ type ExplosionCluster struct {
 }
 
 func initExplosionCluster(g *Game, p rl.Vector2, explosionCount int32) ExplosionCluster {
+	return initColoredExplosionCluster(g, p, explosionCount, colorChoices)
+}
+
+func initColoredExplosionCluster(g *Game, p rl.Vector2, explosionCount int32, colors []rl.Color) ExplosionCluster {
 	cluster := ExplosionCluster{
 		game: g,
 		pos:  p,
@@ -23,11 +27,13 @@ func initExplosionCluster(g *Game, p rl.Vector2, explosionCount int32) Explosion
 	var explosions []Explosion
 	for range explosionCount {
 		pos := rl.Vector2{X: p.X + rand.Float32()*5 - 5, Y: p.Y + rand.Float32()*5 - 5}
-		explosions = append(explosions, initExplosion(&cluster, pos, rand.Float32()*2*math.Pi, rand.Float32()*5.0))
+		explosions = append(explosions, initExplosion(&cluster, pos, rand.Float32()*2*math.Pi, rand.Float32()*5.0, colors))
 	}
 	cluster.explosions = explosions
 
-	rl.PlaySound(g.explosionSound)
+	if !g.headless {
+		g.mixer.play(EXPLOSION_SOUND, 1, 1)
+	}
 
 	return cluster
 }
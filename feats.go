@@ -0,0 +1,122 @@
+package main
+
+import (
+	"fmt"
+	"math"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+const SHIP_HISTORY_LENGTH int = 600 // ten seconds; longer passes are judged on the part still in the window
+const ORBIT_RANGE_MULTIPLE float32 = 12
+const ORBIT_SCORE int32 = 1000
+const CLOSE_PASS_MULTIPLE float32 = 2.5
+const CLOSE_PASS_SCORE float32 = 300
+const ASSIST_RANGE_MULTIPLE float32 = 6
+const ASSIST_MIN_GAIN float32 = 1.5
+const ASSIST_SCORE_PER_SPEED float32 = 150
+
+// Ship state recorded each tick
+type ShipSample struct {
+	tick        int32
+	pos         rl.Vector2
+	gravityGain float32 // the ship's running total, so engine and boost changes in speed don't count
+}
+
+// Per black hole progress towards orbit, close pass and slingshot feats
+type FeatTracker struct {
+	orbitSweep  float32 // signed radians swept around the hole while in range
+	inPass      bool
+	passStart   int32 // tick the ship came within close-pass range
+	inAssist    bool
+	assistStart int32 // tick the ship entered the assist zone
+}
+
+func (s *Ship) recordHistory() {
+	s.history = append(s.history, ShipSample{tick: s.game.elapsedTicks, pos: s.pos, gravityGain: s.gravityGain})
+	if len(s.history) > SHIP_HISTORY_LENGTH {
+		s.history = s.history[len(s.history)-SHIP_HISTORY_LENGTH:]
+	}
+}
+
+// The samples from the given tick on, or the whole window if that's older
+func samplesSince(history []ShipSample, tick int32) []ShipSample {
+	for i, sample := range history {
+		if sample.tick >= tick {
+			return history[i:]
+		}
+	}
+	return history[len(history):]
+}
+
+// Nearest the ship came to a point over some samples
+func closestApproach(samples []ShipSample, p rl.Vector2) float32 {
+	closest := float32(math.Inf(1))
+	for _, sample := range samples {
+		closest = min(closest, rl.Vector2Distance(sample.pos, p))
+	}
+	return closest
+}
+
+// Check a ship's latest movement against every black hole for feats worth points
+func (g *Game) detectGravityFeats(s *Ship) {
+	history := s.history
+	if s.isDead || len(history) < 2 {
+		return
+	}
+	previous := history[len(history)-2]
+	current := history[len(history)-1]
+
+	for i := range g.blackHoleList {
+		b := &g.blackHoleList[i]
+		t := &b.feats[s.player]
+		dis := rl.Vector2Distance(current.pos, b.pos)
+		deathRadius := max(b.deathRadius, 1)
+
+		// Orbits: accumulate the angle swept around the hole while nearby
+		if dis < ORBIT_RANGE_MULTIPLE*deathRadius {
+			before := math.Atan2(float64(previous.pos.Y-b.pos.Y), float64(previous.pos.X-b.pos.X))
+			after := math.Atan2(float64(current.pos.Y-b.pos.Y), float64(current.pos.X-b.pos.X))
+			delta := math.Remainder(after-before, 2*math.Pi)
+			t.orbitSweep += float32(delta)
+			if math.Abs(float64(t.orbitSweep)) >= 2*math.Pi {
+				t.orbitSweep = 0
+				g.awardFeat(s.player, current.pos, ORBIT_SCORE, "Orbit!")
+			}
+		} else {
+			t.orbitSweep = 0
+		}
+
+		// Close passes: score on the way out, more for skimming closer
+		if dis < CLOSE_PASS_MULTIPLE*deathRadius {
+			if !t.inPass {
+				t.inPass = true
+				t.passStart = current.tick
+			}
+		} else if t.inPass {
+			t.inPass = false
+			closest := closestApproach(samplesSince(history, t.passStart), b.pos)
+			depth := 1 - (closest-deathRadius)/((CLOSE_PASS_MULTIPLE-1)*deathRadius)
+			g.awardFeat(s.player, current.pos, int32(CLOSE_PASS_SCORE*(1+max(0, depth))), "Close pass")
+		}
+
+		// Gravity assists: leave the hole's influence faster than we arrived
+		if dis < ASSIST_RANGE_MULTIPLE*deathRadius {
+			if !t.inAssist {
+				t.inAssist = true
+				t.assistStart = previous.tick
+			}
+		} else if t.inAssist {
+			t.inAssist = false
+			gain := current.gravityGain - samplesSince(history, t.assistStart)[0].gravityGain
+			if gain >= ASSIST_MIN_GAIN {
+				g.awardFeat(s.player, current.pos, int32(gain*ASSIST_SCORE_PER_SPEED), fmt.Sprintf("Slingshot +%.1f", gain))
+			}
+		}
+	}
+}
+
+func (g *Game) awardFeat(player int, p rl.Vector2, points int32, name string) {
+	g.awardPoints(player, points)
+	g.addCallout(rl.Vector2{X: p.X, Y: p.Y - 30}, fmt.Sprintf("%s +%d", name, points), rl.SkyBlue)
+}
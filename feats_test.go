@@ -0,0 +1,131 @@
+package main
+
+import (
+	"testing"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+func sampleRun(ticks ...int32) []ShipSample {
+	history := []ShipSample{}
+	for _, tick := range ticks {
+		history = append(history, ShipSample{tick: tick, pos: rl.Vector2{X: float32(tick)}})
+	}
+	return history
+}
+
+func TestSamplesSince(t *testing.T) {
+	history := sampleRun(10, 11, 12, 13, 14)
+
+	tests := []struct {
+		name  string
+		tick  int32
+		first int32
+		count int
+	}{
+		{"inside the window", 12, 12, 3},
+		{"latest sample", 14, 14, 1},
+		{"older than the window", 5, 10, 5},
+		{"after the latest sample", 20, 0, 0},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := samplesSince(history, test.tick)
+			if len(got) != test.count {
+				t.Fatalf("%d samples, want %d", len(got), test.count)
+			}
+			if test.count > 0 && got[0].tick != test.first {
+				t.Errorf("starts at tick %d, want %d", got[0].tick, test.first)
+			}
+		})
+	}
+}
+
+func TestClosestApproach(t *testing.T) {
+	samples := []ShipSample{
+		{pos: rl.Vector2{X: -30, Y: 40}},
+		{pos: rl.Vector2{X: 0, Y: 20}},
+		{pos: rl.Vector2{X: 30, Y: 40}},
+	}
+	if got := closestApproach(samples, rl.Vector2{}); got != 20 {
+		t.Errorf("closest approach %v, want 20", got)
+	}
+	if got := closestApproach(samples[:0], rl.Vector2{}); got < 1e30 {
+		t.Errorf("closest approach with no samples %v, want infinity", got)
+	}
+}
+
+// Fly a ship left to right past a hole at the origin, y above it, feeding the feat detector each tick
+func flyPast(g *Game, y float32, gain func(x float32) float32) {
+	s := &g.ships[0]
+	for x := float32(-200); x <= 200; x += 5 {
+		g.elapsedTicks += 1
+		s.pos = rl.Vector2{X: x, Y: y}
+		s.gravityGain = gain(x)
+		s.recordHistory()
+		g.detectGravityFeats(s)
+	}
+}
+
+func featGame() *Game {
+	g := &Game{}
+	g.ships = []Ship{{game: g, history: []ShipSample{}}}
+	g.blackHoleList = []BlackHole{{deathRadius: 10}}
+	return g
+}
+
+func noGain(x float32) float32 {
+	return 0
+}
+
+func TestClosePass(t *testing.T) {
+	tests := []struct {
+		name     string
+		y        float32
+		min, max int32
+	}{
+		{"skimming the death radius", 11, 570, 600},
+		{"halfway out", 17.5, 440, 460},
+		{"edge of range", 24, 300, 330},
+		{"out of range", 40, 0, 0},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			g := featGame()
+			flyPast(g, test.y, noGain)
+			if g.score < test.min || g.score > test.max {
+				t.Errorf("scored %d, want %d to %d", g.score, test.min, test.max)
+			}
+		})
+	}
+}
+
+func TestSlingshot(t *testing.T) {
+	// Gain builds up while the ship is in the assist zone, out of close-pass range
+	ramp := func(total float32) func(x float32) float32 {
+		return func(x float32) float32 {
+			return total * min(1, max(0, (x+40)/80))
+		}
+	}
+
+	tests := []struct {
+		name  string
+		gain  func(x float32) float32
+		score int32
+	}{
+		{"big enough gain", ramp(2), int32(2 * ASSIST_SCORE_PER_SPEED)},
+		{"too small a gain", ramp(1), 0},
+		{"no gain", noGain, 0},
+		// Speed picked up before entering the zone isn't the hole's doing
+		{"gain from before the zone", func(x float32) float32 { return 5 }, 0},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			g := featGame()
+			flyPast(g, 40, test.gain)
+			if g.score != test.score {
+				t.Errorf("scored %d, want %d", g.score, test.score)
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"math/rand"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// Anything that bends the paths of the ship and asteroids
+type FieldSource interface {
+	calculateForceOnObject(obj rl.Vector2) rl.Vector2
+}
+
+type BodyKind int
+
+const (
+	BlackHoleBody BodyKind = iota
+	RotatingHoleBody
+	WhiteHoleBody
+	WormholeBody
+)
+
+// Relative chance of a collapsing star becoming each kind of body
+type CollapseRule struct {
+	kind   BodyKind
+	weight float32
+}
+
+var collapseRules = []CollapseRule{
+	{kind: BlackHoleBody, weight: 6},
+	{kind: RotatingHoleBody, weight: 2},
+	{kind: WhiteHoleBody, weight: 1.5},
+	{kind: WormholeBody, weight: 1},
+}
+
+func chooseCollapseBody(rng *rand.Rand, rules []CollapseRule) BodyKind {
+	total := float32(0)
+	for _, rule := range rules {
+		total += rule.weight
+	}
+
+	roll := rng.Float32() * total
+	for _, rule := range rules {
+		roll -= rule.weight
+		if roll < 0 {
+			return rule.kind
+		}
+	}
+	return BlackHoleBody
+}
+
+// Collect the bodies currently exerting force; call after the body lists change and before anything feels the field
+func (g *Game) refreshFieldSources() {
+	g.fieldSourceList = g.fieldSourceList[:0]
+	for i := range g.blackHoleList {
+		g.fieldSourceList = append(g.fieldSourceList, &g.blackHoleList[i])
+	}
+	for i := range g.whiteHoleList {
+		g.fieldSourceList = append(g.fieldSourceList, &g.whiteHoleList[i])
+	}
+	for i := range g.wormholeList {
+		g.fieldSourceList = append(g.fieldSourceList, &g.wormholeList[i])
+	}
+}
+
+// Sum of the forces from every body on a point
+func (g *Game) calculateFieldForce(obj rl.Vector2) rl.Vector2 {
+	force := rl.Vector2{}
+	for _, source := range g.fieldSourceList {
+		force = rl.Vector2Add(force, source.calculateForceOnObject(obj))
+	}
+	return force
+}
+
+// Replace a star with a body picked by the collapse rules
+func (g *Game) collapseStar(p rl.Vector2) {
+	switch chooseCollapseBody(g.rng, collapseRules) {
+	case BlackHoleBody:
+		g.addBlackHole(p)
+	case RotatingHoleBody:
+		g.blackHoleList = append(g.blackHoleList, initRotatingBlackHole(g, p, 45.0))
+	case WhiteHoleBody:
+		g.whiteHoleList = append(g.whiteHoleList, initWhiteHole(g, p, 45.0))
+	case WormholeBody:
+		g.wormholeList = append(g.wormholeList, initWormhole(g, p, g.findSpawnPoint(starSpawnRules)))
+	}
+}
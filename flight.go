@@ -0,0 +1,202 @@
+package main
+
+import (
+	"math"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+type FlightModel int
+
+const (
+	// Heading-locked engine speed on top of a gravity-only velocity
+	Arcade FlightModel = iota
+	// Thrust adds to a single velocity vector and turning has momentum
+	Newtonian
+)
+
+func (f FlightModel) name() string {
+	if f == Newtonian {
+		return "Newtonian"
+	}
+	return "Arcade"
+}
+
+func (f FlightModel) next() FlightModel {
+	return (f + 1) % 2
+}
+
+// Handling parameters for a type of ship
+type ShipHandling struct {
+	// Arcade model
+	maxEngineSpeed float64
+	throttleStep   float64
+	maxSpeed       float64 // cap on each component of the gravity velocity
+	turnRate       float32 // radians per tick
+	gravityScale   float32 // how strongly the field pulls on the ship
+	fuelCapacity   float32
+
+	// Newtonian model
+	thrust              float32
+	retroThrust         float32
+	strafeThrust        float32
+	maxNewtonianSpeed   float32
+	angularAcceleration float32
+	angularDamping      float32 // fraction of angular velocity kept each tick
+	maxAngularVelocity  float32
+}
+
+var standardHandling = ShipHandling{
+	maxEngineSpeed:      MAX_ENGINE_SPEED,
+	throttleStep:        0.1,
+	maxSpeed:            MAX_SPEED,
+	turnRate:            math.Pi / 60,
+	gravityScale:        1,
+	fuelCapacity:        100,
+	thrust:              0.12,
+	retroThrust:         0.08,
+	strafeThrust:        0.06,
+	maxNewtonianSpeed:   10,
+	angularAcceleration: 0.006,
+	angularDamping:      0.9,
+	maxAngularVelocity:  math.Pi / 30,
+}
+
+// Control inputs for one tick, each in [-1, 1]
+type ShipControls struct {
+	turn      float32             // positive turns clockwise
+	throttle  float32             // positive accelerates, negative decelerates or fires retro thrusters
+	strafe    float32             // positive slides to the right of the heading
+	abilities [MAX_ABILITIES]bool // ability slots to trigger this tick
+}
+
+// Keyboard controls for one player
+func readKeyboardControls(b KeyBindings) ShipControls {
+	controls := ShipControls{}
+	if rl.IsKeyDown(b.right) {
+		controls.turn += 1
+	}
+	if rl.IsKeyDown(b.left) {
+		controls.turn -= 1
+	}
+	if rl.IsKeyDown(b.up) {
+		controls.throttle += 1
+	}
+	if rl.IsKeyDown(b.down) {
+		controls.throttle -= 1
+	}
+	if rl.IsKeyDown(b.strafeRight) {
+		controls.strafe += 1
+	}
+	if rl.IsKeyDown(b.strafeLeft) {
+		controls.strafe -= 1
+	}
+	for i, key := range b.abilities {
+		controls.abilities[i] = rl.IsKeyPressed(key)
+	}
+	return controls
+}
+
+// Gamepad controls layered on top of the keyboard: left stick steers, triggers throttle, bumpers strafe, face buttons fire abilities
+func addGamepadControls(controls ShipControls, gamepad int32) ShipControls {
+	if !rl.IsGamepadAvailable(gamepad) {
+		return controls
+	}
+	if stick := rl.GetGamepadAxisMovement(gamepad, rl.GamepadAxisLeftX); math.Abs(float64(stick)) > 0.2 {
+		controls.turn = stick
+	}
+	if rl.IsGamepadButtonDown(gamepad, rl.GamepadButtonRightTrigger2) {
+		controls.throttle = 1
+	}
+	if rl.IsGamepadButtonDown(gamepad, rl.GamepadButtonLeftTrigger2) {
+		controls.throttle = -1
+	}
+	if rl.IsGamepadButtonDown(gamepad, rl.GamepadButtonRightTrigger1) {
+		controls.strafe = 1
+	}
+	if rl.IsGamepadButtonDown(gamepad, rl.GamepadButtonLeftTrigger1) {
+		controls.strafe = -1
+	}
+	faceButtons := [MAX_ABILITIES]int32{
+		rl.GamepadButtonRightFaceDown,
+		rl.GamepadButtonRightFaceRight,
+		rl.GamepadButtonRightFaceLeft,
+		rl.GamepadButtonRightFaceUp,
+	}
+	for i, button := range faceButtons {
+		controls.abilities[i] = controls.abilities[i] || rl.IsGamepadButtonPressed(gamepad, button)
+	}
+	return controls
+}
+
+func (s *Ship) applyControls(c ShipControls) {
+	if s.isDead {
+		return
+	}
+
+	for i, use := range c.abilities {
+		if use {
+			s.activateAbility(i)
+		}
+	}
+
+	// Remember the steering so the predicted path can assume it stays held
+	c.abilities = [MAX_ABILITIES]bool{}
+	s.heldControls = c
+	s.steer(c)
+}
+
+// Turn and fire the engines for one tick
+func (s *Ship) steer(c ShipControls) {
+	// No fuel, no engine
+	if s.game.fuelMode && s.fuel <= 0 {
+		c.throttle = 0
+		c.strafe = 0
+	}
+
+	switch s.flightModel {
+	case Arcade:
+		s.angle += c.turn * s.handling.turnRate
+		if c.throttle > 0 {
+			s.increaseSpeed()
+		}
+		if c.throttle < 0 {
+			s.decreaseSpeed()
+		}
+	case Newtonian:
+		s.angularVelocity += c.turn * s.handling.angularAcceleration
+
+		heading := float64(s.angle)
+		thrust := float32(0)
+		if c.throttle > 0 {
+			thrust = c.throttle * s.handling.thrust
+		} else {
+			thrust = c.throttle * s.handling.retroThrust
+		}
+		s.velocity = rl.Vector2Add(s.velocity, headingVelocity(heading, thrust))
+		s.velocity = rl.Vector2Add(s.velocity, headingVelocity(heading+math.Pi/2, c.strafe*s.handling.strafeThrust))
+
+		// Engine effects follow how hard the thrusters are firing
+		s.thrustLevel = math.Min(1, math.Abs(float64(c.throttle))+math.Abs(float64(c.strafe)))
+	}
+}
+
+// Keep the arcade engine between stopped and full speed
+func (s *Ship) limitEngineSpeed() {
+	s.engineSpeed = math.Min(s.handling.maxEngineSpeed, math.Max(0, float64(s.engineSpeed)))
+}
+
+// Newtonian rotation: spin with momentum, damped each tick
+func (s *Ship) rotate() {
+	s.angularVelocity = float32(math.Max(-float64(s.handling.maxAngularVelocity), math.Min(float64(s.handling.maxAngularVelocity), float64(s.angularVelocity))))
+	s.angle += s.angularVelocity
+	s.angularVelocity *= s.handling.angularDamping
+}
+
+// How hard the engine is working, from 0 to 1
+func (s *Ship) enginePower() float64 {
+	if s.flightModel == Newtonian {
+		return s.thrustLevel
+	}
+	return s.engineSpeed / s.handling.maxEngineSpeed
+}
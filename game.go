@@ -5,114 +5,215 @@ import (
 	"image/color"
 	"math"
 	"math/rand"
+	"os"
+	"strings"
+	"time"
 
 	rl "github.com/gen2brain/raylib-go/raylib"
 )
 
-const WindowHeight int = 972
-const WindowWidth int = 1728
+const DEFAULT_WINDOW_WIDTH int = 1728
+const DEFAULT_WINDOW_HEIGHT int = 972
+
 const MaxStars int = 5
 
+// Starting range of ticks between asteroid waves; it narrows as the run goes on, and simulate can override it
+var initialAsteroidCountdownRange = rl.Vector2{X: 180, Y: 300}
+
 type Game struct {
 	// Game state
-	gameState State
-
-	// Textures
-	backgroundTexture rl.Texture2D
-	shipTexture       rl.Texture2D
-	asteroidTexture   rl.Texture2D
-	blackHoleTexture  rl.Texture2D
-	starTexture       rl.Texture2D
-
-	// Sounds
-	music          rl.Sound
-	explosionSound rl.Sound
-	engineSound    rl.Sound
+	gameState      State
+	difficulty     Difficulty
+	flightModel    FlightModel
+	fuelMode       bool
+	collectStars   bool
+	mode           GameMode
+	selectedShips  [MAX_PLAYERS]int
+	highScores     HighScores
+	newHighScore   bool
+	showTrajectory bool
+	seed           int64
+	rng            *rand.Rand // drives everything in the simulation so a seed reproduces a run
+	headless       bool       // no window or audio; the simulation runs as fast as it can
+	width          int        // of the window and the play field; a replay or network game brings its own
+	height         int
+	bots           [MAX_PLAYERS]Bot
+	controls       [MAX_PLAYERS]ShipControls // read by handleInput, applied on the next tick
+	fixedSeed      int64                     // seed every run with this instead of the clock, if set
+	recordPath     string                    // save a replay of each run here, if set
+	recording      *Replay
+	playback       *Replay
+
+	// Network play
+	net         *NetSession
+	netStatus   string
+	hostAddress string
+	joinAddress string
+
+	// Sprites and sounds, owned by the asset manager and updated in place when their files change
+	assets           *AssetManager
+	backgroundSprite *Sprite
+	shipSprites      []*Sprite // indexed like shipRoster
+	asteroidSprite   *Sprite
+	blackHoleSprite  *Sprite
+	starSprite       *Sprite
+	music            *MusicPlayer
+	mixer            *Mixer
+	engineVoice      int // mixer voice the engine loop plays on
 
 	// Game components
-	ship                   Ship
+	ships                  []Ship
 	blackHoleList          []BlackHole
+	whiteHoleList          []WhiteHole
+	wormholeList           []Wormhole
 	starList               []Star
 	asteroidList           []Asteroid
+	fragmentList           []Asteroid
+	debrisList             []Debris
 	explosionClusterList   []ExplosionCluster
-	addedFinalExplosion    bool
+	calloutList            []Callout
+	shockwaveList          []Shockwave
+	spawnTelegraphList     []SpawnTelegraph
+	fieldSourceList        []FieldSource // the bodies above, rebuilt by refreshFieldSources
 	restartCounter         int32
+	runDecided             bool
+	winner                 int // in versus, the player left alive when the run was decided, or -1 for a draw
+	worldStepAccumulator   float32
 	asteroidCountdownRange rl.Vector2
 	starAdditionCountdown  int32
 	starMultiplier         int32
 	asteroidCountdown      int32
 	score                  int32
+	lives                  int32 // shared revives in co-op
+	elapsedTicks           int32
+	nextAsteroidID         int32
 }
 
 type State int
 
 const (
 	Start State = iota
+	ShipSelect
 	Play
 	Restart
+	Lobby
 )
 
-func initGame() Game {
+// How to open the window, and where to load assets from
+type WindowConfig struct {
+	width       int
+	height      int
+	fullscreen  bool
+	assetDir    string // files here replace the embedded ones, laid out like assets/; empty for none
+	watchAssets bool   // reload files in assetDir as they change
+}
+
+func initGame(window WindowConfig) Game {
 	// Init game contexts
-	rl.InitWindow(int32(WindowWidth), int32(WindowHeight), "Black Hole Bounce")
+	if window.fullscreen {
+		rl.SetConfigFlags(rl.FlagFullscreenMode)
+	}
+	rl.InitWindow(int32(window.width), int32(window.height), "Black Hole Bounce")
 	rl.InitAudioDevice()
 	rl.SetTargetFPS(60)
 
-	// Load the assets and return game element
-	return Game{
-		gameState:         Start,
-		backgroundTexture: rl.LoadTexture("assets/images/background.png"),
-		shipTexture:       rl.LoadTexture("assets/images/ship.png"),
-		asteroidTexture:   rl.LoadTexture("assets/images/asteroid.png"),
-		blackHoleTexture:  rl.LoadTexture("assets/images/black_hole.png"),
-		starTexture:       rl.LoadTexture("assets/images/star.png"),
-		music:             rl.LoadSound("assets/sound/scifi_background.wav"),
-		explosionSound:    rl.LoadSound("assets/sound/explosion.wav"),
-		engineSound:       rl.LoadSound("assets/sound/engine.wav"),
+	// Load the assets, reporting anything missing or broken
+	assets := initAssetManager(window.assetDir, window.watchAssets, os.Stderr)
+	shipSprites := []*Sprite{}
+	for _, shipType := range shipRoster {
+		shipSprites = append(shipSprites, assets.acquireSprite(shipType.sprite, shipType.frame))
+	}
+	game := Game{
+		gameState:        Start,
+		difficulty:       Normal,
+		highScores:       loadHighScores(),
+		seed:             time.Now().UnixNano(),
+		width:            window.width,
+		height:           window.height,
+		hostAddress:      DEFAULT_HOST_ADDRESS,
+		joinAddress:      DEFAULT_JOIN_ADDRESS,
+		assets:           assets,
+		backgroundSprite: assets.acquireSprite("images/background.png", ""),
+		shipSprites:      shipSprites,
+		asteroidSprite:   assets.acquireSprite("images/asteroid.png", ""),
+		blackHoleSprite:  assets.acquireSprite("images/black_hole.png", ""),
+		starSprite:       assets.acquireSprite("images/star.png", ""),
+		music:            initMusicPlayer(assets, os.Stderr),
 	}
+	game.mixer = initMixer(assets, game.music, os.Stderr)
+	assets.report()
+	return game
 }
 
 // Reload game components (resets to starting state)
 func (g *Game) reloadGameComponents() {
-	g.ship = initShip(g, rl.Vector2{X: float32(WindowWidth) / 2, Y: float32(WindowHeight) / 2}, 5, g.shipTexture)
+	g.rng = rand.New(rand.NewSource(g.seed))
+	g.controls = [MAX_PLAYERS]ShipControls{}
+	g.recording = nil
+	if g.recordPath != "" && g.playback == nil {
+		g.recording = newReplay(g.runSetup())
+	}
+	g.ships = []Ship{}
+	for i := range g.mode.playerCount() {
+		selected := g.selectedShips[i]
+		g.ships = append(g.ships, initShip(g, i, g.spawnPosition(i), shipRoster[selected], g.shipSprites[selected], g.flightModel))
+	}
 	g.blackHoleList = []BlackHole{}
+	g.whiteHoleList = []WhiteHole{}
+	g.wormholeList = []Wormhole{}
+	g.fieldSourceList = []FieldSource{}
 	g.asteroidList = []Asteroid{}
+	g.fragmentList = []Asteroid{}
+	g.debrisList = []Debris{}
 	g.explosionClusterList = []ExplosionCluster{}
-	g.addedFinalExplosion = false
+	g.calloutList = []Callout{}
+	g.shockwaveList = []Shockwave{}
+	g.showTrajectory = g.difficulty.settings().showTrajectory
+	g.spawnTelegraphList = []SpawnTelegraph{}
 	g.restartCounter = 120
-	g.asteroidCountdownRange = rl.Vector2{X: 180, Y: 300}
+	g.runDecided = false
+	g.winner = -1
+	g.worldStepAccumulator = 0
+	g.asteroidCountdownRange = initialAsteroidCountdownRange
 	g.starAdditionCountdown = 1800
 	g.starMultiplier = 1
-	g.asteroidCountdown = int32(rand.Intn(int(g.asteroidCountdownRange.Y-g.asteroidCountdownRange.X))) + int32(g.asteroidCountdownRange.X)
-	starList := []Star{}
+	g.asteroidCountdown = int32(g.rng.Intn(int(g.asteroidCountdownRange.Y-g.asteroidCountdownRange.X))) + int32(g.asteroidCountdownRange.X)
+	g.starList = []Star{}
 	for range MaxStars {
-		starList = append(starList, g.generateRandomStar())
+		g.starList = append(g.starList, g.generateRandomStar())
 	}
-	g.starList = starList
 	g.score = 0
+	g.lives = COOP_LIVES
+	g.elapsedTicks = 0
+	g.nextAsteroidID = 0
+	g.newHighScore = false
 }
 
-// Unload the loaded assets before closing the game
+// Release the assets before closing the game
 func (g *Game) unload() {
-	rl.UnloadTexture(g.backgroundTexture)
-	rl.UnloadTexture(g.shipTexture)
-	rl.UnloadTexture(g.asteroidTexture)
-	rl.UnloadTexture(g.blackHoleTexture)
-	rl.UnloadTexture(g.starTexture)
-	rl.UnloadSound(g.music)
-	rl.UnloadSound(g.explosionSound)
-	rl.UnloadSound(g.engineSound)
+	g.assets.releaseSprite(g.backgroundSprite)
+	for _, sprite := range g.shipSprites {
+		g.assets.releaseSprite(sprite)
+	}
+	g.assets.releaseSprite(g.asteroidSprite)
+	g.assets.releaseSprite(g.blackHoleSprite)
+	g.assets.releaseSprite(g.starSprite)
+	g.mixer.close()
+	g.music.close()
+	g.assets.close()
 }
 
 // Main loop of the game
 func (g *Game) run() {
 	// Boot initial tasks
-	rl.PlaySound(g.music)
 	g.reloadGameComponents()
 
 	for !rl.WindowShouldClose() {
+		g.assets.update()
 		g.handleInput()
 		g.update()
+		g.music.play(g.musicMood())
+		g.mixer.update(rl.GetFrameTime())
 		g.render()
 	}
 
@@ -127,10 +228,15 @@ func (g *Game) render() {
 	rl.ClearBackground(rl.White)
 
 	// Draw the background
-	rl.DrawTexture(g.backgroundTexture, 0, 0, color.RGBA{255, 255, 255, 200})
+	g.backgroundSprite.draw(rl.NewRectangle(0, 0, g.backgroundSprite.width(), g.backgroundSprite.height()), rl.Vector2{}, 0, color.RGBA{255, 255, 255, 200})
 
 	switch g.gameState {
 	case Play:
+		// Render spawn warnings
+		for _, telegraph := range g.spawnTelegraphList {
+			telegraph.render()
+		}
+
 		// Render stars
 		for _, star := range g.starList {
 			star.render()
@@ -141,154 +247,521 @@ func (g *Game) render() {
 			blackHole.render()
 		}
 
+		// Render white holes and wormholes
+		for _, whiteHole := range g.whiteHoleList {
+			whiteHole.render()
+		}
+		for _, wormhole := range g.wormholeList {
+			wormhole.render()
+		}
+
 		// Render asteroids
 		for _, asteroid := range g.asteroidList {
 			fmt.Println("Afdsa")
 			asteroid.render()
 		}
 
+		// Render shockwaves
+		for _, shockwave := range g.shockwaveList {
+			shockwave.render()
+		}
+
 		// Render explosions
 		for _, cluster := range g.explosionClusterList {
 			cluster.render()
 		}
 
-		// Render the ship
-		g.ship.render()
+		// Render warnings for asteroids still off screen
+		g.renderThreatIndicators()
+
+		// Render fuel debris
+		for _, debris := range g.debrisList {
+			debris.render()
+		}
+
+		// Render the predicted paths
+		if g.showTrajectory {
+			for _, ship := range g.ships {
+				if !ship.isDead {
+					ship.trajectory.render()
+				}
+			}
+		}
+
+		// Render the ships
+		for _, ship := range g.ships {
+			ship.render()
+		}
+
+		// Render callouts
+		for _, callout := range g.calloutList {
+			callout.render()
+		}
 
 		// Draw UI elements
-		rl.DrawText(fmt.Sprintf("Score: %d", g.score), 10, 10, 40, rl.RayWhite)
+		g.renderPlayerHUD()
 	case Start:
 		rl.DrawText("Black Hole Bounce", 600, 300, 64, rl.RayWhite)
 		rl.DrawText("Stay Alive as Long as You Can!", 400, 350, 64, rl.RayWhite)
 
 		rl.DrawText("Left/Right arrows: Turn", 600, 450, 48, rl.RayWhite)
 		rl.DrawText("Up/Down arrows: Accelerate/Decelerate", 400, 500, 48, rl.RayWhite)
+		rl.DrawText("Q/W/E: Ship abilities", 600, 550, 48, rl.RayWhite)
 		rl.DrawText("Press Space to Start!", 520, 600, 64, rl.RayWhite)
+		if g.netStatus != "" {
+			rl.DrawText(g.netStatus, int32(g.width)/2-rl.MeasureText(g.netStatus, 32)/2, 660, 32, rl.Gold)
+		}
+		g.renderMenuOptions()
+	case Lobby:
+		g.renderLobby()
+	case ShipSelect:
+		g.renderShipSelect()
 	case Restart:
-		rl.DrawText(fmt.Sprintf("Final Score: %d", g.score), 620, 300, 64, rl.RayWhite)
-		rl.DrawText("Play Again?", 685, 350, 64, rl.RayWhite)
+		g.renderResults()
+		rl.DrawText("Play Again?", 685, 370, 64, rl.RayWhite)
 
 		rl.DrawText("Left/Right arrows: Turn", 600, 450, 48, rl.RayWhite)
 		rl.DrawText("Up/Down arrows: Accelerate/Decelerate", 400, 500, 48, rl.RayWhite)
+		rl.DrawText("Q/W/E: Ship abilities", 600, 550, 48, rl.RayWhite)
 		rl.DrawText("Press Space to Start!", 520, 600, 64, rl.RayWhite)
+		g.renderMenuOptions()
 	}
 
 	rl.EndDrawing()
 }
 
+// Render the selectable options shown on the menu screens
+func (g *Game) renderMenuOptions() {
+	rl.DrawText(fmt.Sprintf("Difficulty: %s (D to change, T in game toggles path)", g.difficulty.settings().name), 400, 700, 32, rl.RayWhite)
+	rl.DrawText(fmt.Sprintf("Flight model: %s (F to change, Z/X strafe in Newtonian)", g.flightModel.name()), 400, 740, 32, rl.RayWhite)
+	rl.DrawText(fmt.Sprintf("Fuel: %s (U to change)", onOff(g.fuelMode)), 400, 780, 32, rl.RayWhite)
+	rl.DrawText(fmt.Sprintf("Collectible stars: %s (C to change)", onOff(g.collectStars)), 400, 820, 32, rl.RayWhite)
+	rl.DrawText(fmt.Sprintf("Mode: %s (M to change, 2P: WASD/1-4 vs arrows/7-0, gamepads too)", g.mode.name()), 400, 860, 32, rl.RayWhite)
+	rl.DrawText(fmt.Sprintf("Network: H to host on %s, J to join %s", g.hostAddress, g.joinAddress), 400, 900, 32, rl.RayWhite)
+	volumes := g.mixer.settings
+	rl.DrawText(fmt.Sprintf("Volume: master %s (V), music %s (B), effects %s (N)", percent(volumes.Master), percent(volumes.Music), percent(volumes.Effects)), 400, 940, 32, rl.RayWhite)
+}
+
+func percent(f float32) string {
+	return fmt.Sprintf("%d%%", int(math.Round(float64(f*100))))
+}
+
+// Render the ship roster with the current choice highlighted
+func (g *Game) renderShipSelect() {
+	rl.DrawText("Choose Your Ship", 580, 150, 64, rl.RayWhite)
+
+	spacing := float32(g.width) / float32(len(shipRoster)+1)
+	for i, shipType := range shipRoster {
+		center := rl.Vector2{X: spacing * float32(i+1), Y: 400}
+		sprite := g.shipSprites[i]
+		scale := float32(4)
+		for player := range g.mode.playerCount() {
+			if i != g.selectedShips[player] {
+				continue
+			}
+			ringColor := rl.Gold
+			if g.mode != Solo {
+				ringColor = playerColors[player]
+				rl.DrawText(fmt.Sprintf("P%d", player+1), int32(center.X)-80+int32(player)*130, 300, 32, ringColor)
+			}
+			rl.DrawCircleLines(int32(center.X), int32(center.Y), 90+float32(player)*8, ringColor)
+		}
+		sprite.draw(
+			rl.NewRectangle(center.X, center.Y, sprite.width()*scale, sprite.height()*scale),
+			rl.Vector2{X: sprite.width() * scale / 2, Y: sprite.height() * scale / 2},
+			0,
+			shipType.tint,
+		)
+		nameWidth := rl.MeasureText(shipType.name, 40)
+		rl.DrawText(shipType.name, int32(center.X)-nameWidth/2, 510, 40, rl.RayWhite)
+		best := fmt.Sprintf("Best: %d", g.highScores[shipType.name])
+		rl.DrawText(best, int32(center.X)-rl.MeasureText(best, 28)/2, 560, 28, rl.RayWhite)
+	}
+
+	if g.mode != Solo {
+		rl.DrawText("P1: A/D to choose   P2: Left/Right to choose   Space to launch", 300, 840, 40, rl.RayWhite)
+		return
+	}
+
+	selected := shipRoster[g.selectedShips[0]]
+	h := selected.handling
+	rl.DrawText(fmt.Sprintf("Turn rate: %.1f deg/tick   Top engine speed: %.0f   Gravity: x%.2f   Size: %.0f", h.turnRate*180/math.Pi, h.maxEngineSpeed, h.gravityScale, selected.radius), 300, 660, 32, rl.RayWhite)
+	rl.DrawText(selected.special.description(), 300, 710, 32, rl.RayWhite)
+	abilityNames := []string{}
+	for _, kind := range selected.abilities {
+		abilityNames = append(abilityNames, abilityDefinitions[kind].name)
+	}
+	rl.DrawText("Abilities: "+strings.Join(abilityNames, ", "), 300, 760, 32, rl.RayWhite)
+	rl.DrawText("Left/Right to choose, Space to launch", 480, 840, 48, rl.RayWhite)
+}
+
+func onOff(b bool) string {
+	if b {
+		return "On"
+	}
+	return "Off"
+}
+
 // Process game logic updates
 func (g *Game) update() {
-	if !rl.IsSoundPlaying(g.music) {
-		rl.PlaySound(g.music)
+	if g.net != nil {
+		g.net.pump()
 	}
 
 	if g.gameState == Play {
+		// Take this tick's controls from the network, a replay, or whatever handleInput read.
+		// A network game waits here until both players' inputs have arrived.
+		if g.net != nil {
+			controls, ok := g.net.nextTickControls()
+			if !ok {
+				return
+			}
+			g.controls = controls
+		} else if g.playback != nil {
+			controls, ok := g.playback.next()
+			if !ok {
+				g.endRun()
+				return
+			}
+			g.controls = controls
+		}
+		for i := range g.ships {
+			g.ships[i].applyControls(g.controls[i])
+		}
+		if g.recording != nil {
+			g.recording.record(g.controls, len(g.ships))
+		}
+		g.controls = [MAX_PLAYERS]ShipControls{}
+
+		// Blow up any ships that died last tick
+		for i := range g.ships {
+			ship := &g.ships[i]
+			if ship.isDead && !ship.exploded {
+				g.createNewExplosion(ship.pos, 50)
+				ship.exploded = true
+				if !g.headless {
+					g.mixer.duckMusic()
+				}
+			}
+		}
+
 		// Process end-game components
-		if g.ship.isDead {
-			if !g.addedFinalExplosion {
-				g.createNewExplosion(g.ship.pos, 50)
-				g.addedFinalExplosion = true
+		if g.isRunOver() {
+			if !g.runDecided {
+				g.decideRun()
 			}
 			g.restartCounter -= 1
 		}
 		if g.restartCounter <= 0 {
-			g.gameState = Restart
+			g.endRun()
 		}
 
 		// Increase the score
-		if !g.ship.isDead {
-			g.score += 1
+		for _, ship := range g.ships {
+			if !ship.isDead {
+				g.awardPoints(ship.player, 1)
+				if g.mode != Versus {
+					break
+				}
+			}
 		}
+		g.elapsedTicks += 1
 
-		// Process asteroid event
-		g.asteroidCountdown -= 1
-		if g.asteroidCountdown <= 0 {
-			g.createNewAsteroid()
-		}
+		// Abilities may have added bodies, and the ships and their predicted paths share this field
+		g.refreshFieldSources()
 
-		// Process star event
-		g.starAdditionCountdown -= 1
-		if g.starAdditionCountdown <= 0 {
-			g.starMultiplier = int32(math.Min(5, float64(g.starMultiplier+1)))
-			g.starAdditionCountdown = 1800
+		// Update the ships
+		for i := range g.ships {
+			g.ships[i].update()
 		}
+		g.bumpShips()
+		g.reviveWrecks()
+		g.updateEngineSound()
 
-		// Update the ship
-		g.ship.update()
+		for i := range g.ships {
+			ship := &g.ships[i]
 
-		// Update the black holes
-		newBlackholeList := []BlackHole{}
-		for _, blackHole := range g.blackHoleList {
-			blackHole.update()
+			// Score skilful use of gravity
+			g.detectGravityFeats(ship)
 
-			if blackHole.radius > DECAY_RATE {
-				newBlackholeList = append(newBlackholeList, blackHole)
-			} else {
-				for range g.starMultiplier {
-					g.starList = append(g.starList, g.generateRandomStar())
-				}
+			// Pick up any stars the ship flies through
+			if g.collectStars && !ship.isDead {
+				g.collectTouchedStars(ship)
 			}
 		}
-		g.blackHoleList = newBlackholeList
 
-		// Update the stars
-		newStarList := []Star{}
-		for _, star := range g.starList {
-			star.update()
-
-			if star.detonationCounter > 0 {
-				newStarList = append(newStarList, star)
-			} else {
-				g.addBlackHole(star.pos)
+		// Update the callouts
+		newCalloutList := []Callout{}
+		for _, callout := range g.calloutList {
+			callout.update()
+			if callout.lifetime > 0 {
+				newCalloutList = append(newCalloutList, callout)
 			}
 		}
-		g.starList = newStarList
+		g.calloutList = newCalloutList
 
-		// Update the asteroids
-		newAsteroidList := []Asteroid{}
-		for _, asteroid := range g.asteroidList {
-			asteroid.update()
-			if asteroid.isAlive {
-				newAsteroidList = append(newAsteroidList, asteroid)
+		// Predict where each ship is headed
+		for i := range g.ships {
+			ship := &g.ships[i]
+			if !g.showTrajectory || ship.isDead {
+				continue
+			}
+			ticks := g.difficulty.settings().trajectoryTicks
+			if ship.special == LongSightSpecial {
+				ticks *= 2
 			}
+			ship.trajectory = g.predictTrajectory(ship, ticks)
 		}
-		g.asteroidList = newAsteroidList
 
-		// Update the explosions
-		newExplosionClusterList := []ExplosionCluster{}
-		for _, cluster := range g.explosionClusterList {
-			cluster.update()
+		// Advance the world, which runs slow during bullet time
+		g.worldStepAccumulator += g.timeScale()
+		for g.worldStepAccumulator >= 1 {
+			g.worldStepAccumulator -= 1
+			g.updateWorld()
+		}
 
-			if len(cluster.explosions) > 0 {
-				newExplosionClusterList = append(newExplosionClusterList, cluster)
+		if g.net != nil {
+			g.net.finishTick()
+		}
+
+		// Save the replay once the run's last tick is done
+		if g.gameState != Play && g.recording != nil {
+			g.saveRecording()
+		}
+
+	}
+}
+
+// Advance everything other than the ship by one step
+func (g *Game) updateWorld() {
+	// Process asteroid event
+	g.asteroidCountdown -= 1
+	if g.asteroidCountdown <= 0 {
+		g.createNewAsteroid()
+	}
+
+	// Process star event
+	g.starAdditionCountdown -= 1
+	if g.starAdditionCountdown <= 0 {
+		g.starMultiplier = int32(math.Min(5, float64(g.starMultiplier+1)))
+		g.starAdditionCountdown = 1800
+	}
+
+	// Update the black holes
+	newBlackholeList := []BlackHole{}
+	for _, blackHole := range g.blackHoleList {
+		blackHole.update()
+
+		if blackHole.stage != FinalBurst {
+			newBlackholeList = append(newBlackholeList, blackHole)
+		} else {
+			g.hawkingBurst(blackHole.pos)
+			if !blackHole.isBomb {
+				g.respawnStars(blackHole.pos)
 			}
 		}
-		g.explosionClusterList = newExplosionClusterList
+	}
+	g.blackHoleList = newBlackholeList
+	g.mergeBlackHoles()
+
+	// Update the white holes
+	newWhiteHoleList := []WhiteHole{}
+	for _, whiteHole := range g.whiteHoleList {
+		whiteHole.update()
+
+		if whiteHole.radius > decayRate {
+			newWhiteHoleList = append(newWhiteHoleList, whiteHole)
+		} else {
+			g.respawnStars(whiteHole.pos)
+		}
+	}
+	g.whiteHoleList = newWhiteHoleList
 
+	// Update the wormholes
+	newWormholeList := []Wormhole{}
+	for _, wormhole := range g.wormholeList {
+		wormhole.update()
+
+		if wormhole.lifetime > 0 {
+			newWormholeList = append(newWormholeList, wormhole)
+		} else {
+			g.respawnStars(wormhole.mouths[0])
+		}
 	}
+	g.wormholeList = newWormholeList
+
+	// Update the pending spawns
+	newSpawnTelegraphList := []SpawnTelegraph{}
+	for _, telegraph := range g.spawnTelegraphList {
+		telegraph.update()
+
+		if telegraph.counter > 0 {
+			newSpawnTelegraphList = append(newSpawnTelegraphList, telegraph)
+		} else {
+			g.completeSpawn(telegraph)
+		}
+	}
+	g.spawnTelegraphList = newSpawnTelegraphList
+
+	// Update the stars
+	newStarList := []Star{}
+	for _, star := range g.starList {
+		star.update()
+
+		if star.detonationCounter > 0 {
+			newStarList = append(newStarList, star)
+		} else {
+			g.supernova(star.pos)
+		}
+	}
+	g.starList = newStarList
+
+	// Update the shockwaves
+	newShockwaveList := []Shockwave{}
+	for _, shockwave := range g.shockwaveList {
+		shockwave.update()
+
+		if !shockwave.isDone() {
+			newShockwaveList = append(newShockwaveList, shockwave)
+		}
+	}
+	g.shockwaveList = newShockwaveList
+
+	// The bodies have moved, merged, burst or collapsed out of stars since the ships felt them
+	g.refreshFieldSources()
+
+	// Update the asteroids
+	newAsteroidList := []Asteroid{}
+	for _, asteroid := range g.asteroidList {
+		asteroid.update()
+		if asteroid.isAlive {
+			newAsteroidList = append(newAsteroidList, asteroid)
+		}
+	}
+	g.asteroidList = append(newAsteroidList, g.fragmentList...)
+	g.fragmentList = []Asteroid{}
+
+	// Update the fuel debris
+	newDebrisList := []Debris{}
+	for _, debris := range g.debrisList {
+		debris.update()
+		if debris.isAlive() {
+			newDebrisList = append(newDebrisList, debris)
+		}
+	}
+	g.debrisList = newDebrisList
+
+	// Update the explosions
+	newExplosionClusterList := []ExplosionCluster{}
+	for _, cluster := range g.explosionClusterList {
+		cluster.update()
+
+		if len(cluster.explosions) > 0 {
+			newExplosionClusterList = append(newExplosionClusterList, cluster)
+		}
+	}
+	g.explosionClusterList = newExplosionClusterList
 }
 
 // Handle user input
 func (g *Game) handleInput() {
 	switch g.gameState {
 	case Play:
-		if rl.IsKeyDown(rl.KeyRight) {
-			g.ship.angle += math.Pi / 60
-		}
-		if rl.IsKeyDown(rl.KeyLeft) {
-			g.ship.angle -= math.Pi / 60
+		if g.net != nil {
+			g.net.recordLocalInput(g.playerControls(g.net.localPlayer))
+			if rl.IsKeyPressed(rl.KeyBackspace) {
+				g.net.send(NetMessage{Kind: ByeMessage})
+				g.leaveNetSession("Left the session")
+			}
+		} else if g.playback == nil {
+			for i := range g.ships {
+				g.controls[i] = g.playerControls(i)
+			}
 		}
-		if rl.IsKeyDown(rl.KeyUp) {
-			g.ship.increaseSpeed()
+		if rl.IsKeyPressed(rl.KeyT) {
+			g.showTrajectory = !g.showTrajectory
 		}
-		if rl.IsKeyDown(rl.KeyDown) {
-			g.ship.decreaseSpeed()
+	case ShipSelect:
+		for player := range g.mode.playerCount() {
+			b := g.keyBindings(player)
+			if rl.IsKeyPressed(b.right) {
+				g.selectedShips[player] = (g.selectedShips[player] + 1) % len(shipRoster)
+			}
+			if rl.IsKeyPressed(b.left) {
+				g.selectedShips[player] = (g.selectedShips[player] + len(shipRoster) - 1) % len(shipRoster)
+			}
 		}
-	case Start, Restart:
 		if rl.IsKeyPressed(rl.KeySpace) {
+			g.seed = g.fixedSeed
+			if g.seed == 0 {
+				g.seed = time.Now().UnixNano()
+			}
 			g.reloadGameComponents()
 			g.gameState = Play
 		}
+	case Lobby:
+		if rl.IsKeyPressed(rl.KeyBackspace) {
+			g.net.send(NetMessage{Kind: ByeMessage})
+			g.leaveNetSession("")
+		}
+	case Start, Restart:
+		if rl.IsKeyPressed(rl.KeyD) {
+			g.difficulty = g.difficulty.next()
+		}
+		if rl.IsKeyPressed(rl.KeyF) {
+			g.flightModel = g.flightModel.next()
+		}
+		if rl.IsKeyPressed(rl.KeyU) {
+			g.fuelMode = !g.fuelMode
+		}
+		if rl.IsKeyPressed(rl.KeyC) {
+			g.collectStars = !g.collectStars
+		}
+		if rl.IsKeyPressed(rl.KeyM) {
+			g.mode = g.mode.next()
+		}
+		if rl.IsKeyPressed(rl.KeyV) {
+			g.mixer.cycleVolume(MasterBus)
+		}
+		if rl.IsKeyPressed(rl.KeyB) {
+			g.mixer.cycleVolume(MusicBus)
+		}
+		if rl.IsKeyPressed(rl.KeyN) {
+			g.mixer.cycleVolume(EffectsBus)
+		}
+		if rl.IsKeyPressed(rl.KeyH) {
+			g.enterNetSession(true)
+		}
+		if rl.IsKeyPressed(rl.KeyJ) {
+			g.enterNetSession(false)
+		}
+		if rl.IsKeyPressed(rl.KeySpace) {
+			g.gameState = ShipSelect
+		}
+	}
+}
+
+// Finish the run, saving a solo human score against the ship flown
+func (g *Game) endRun() {
+	g.gameState = Restart
+	if g.headless {
+		return
+	}
+	g.mixer.stop(ENGINE_SOUND)
+	if g.net != nil {
+		g.net.finish()
+		g.net = nil
+	}
+	if g.mode != Solo || g.bots[0] != nil || g.playback != nil {
+		g.playback = nil
+		g.newHighScore = false
+		return
+	}
+	g.newHighScore = g.highScores.record(shipRoster[g.selectedShips[0]].name, g.score)
+	if g.newHighScore {
+		if err := g.highScores.save(); err != nil {
+			fmt.Fprintf(os.Stderr, "could not save high scores: %v\n", err)
+		}
 	}
 }
 
@@ -296,59 +769,143 @@ func (g *Game) addBlackHole(p rl.Vector2) {
 	g.blackHoleList = append(g.blackHoleList, initBlackHole(g, p, 45.0))
 }
 
+// Merge any black holes whose death radii overlap
+func (g *Game) mergeBlackHoles() {
+	merged := []BlackHole{}
+	for _, blackHole := range g.blackHoleList {
+		absorbed := false
+		for i := range merged {
+			if rl.CheckCollisionCircles(merged[i].pos, merged[i].deathRadius, blackHole.pos, blackHole.deathRadius) {
+				merged[i].merge(blackHole)
+				g.createNewExplosion(merged[i].pos, 30)
+				absorbed = true
+				break
+			}
+		}
+		if !absorbed {
+			merged = append(merged, blackHole)
+		}
+	}
+	g.blackHoleList = merged
+}
+
+// Blow a star apart, leaving a body behind
+func (g *Game) supernova(p rl.Vector2) {
+	g.createNewExplosion(p, 40)
+	g.shockwaveList = append(g.shockwaveList, initShockwave(g, p))
+	g.collapseStar(p)
+}
+
+// Final evaporation of a black hole: a flash of radiation and a repulsive kick
+func (g *Game) hawkingBurst(p rl.Vector2) {
+	g.explosionClusterList = append(g.explosionClusterList, initColoredExplosionCluster(g, p, 30, hawkingColorChoices))
+	g.shockwaveList = append(g.shockwaveList, initHawkingBurst(g, p))
+}
+
+// Defuse and collect any stars touching a ship
+func (g *Game) collectTouchedStars(s *Ship) {
+	newStarList := []Star{}
+	for _, star := range g.starList {
+		if !rl.CheckCollisionCircles(s.pos, s.radius, star.pos, STAR_COLLECT_RADIUS) {
+			newStarList = append(newStarList, star)
+			continue
+		}
+
+		points := star.collectionScore()
+		g.awardPoints(s.player, points)
+		g.addCallout(star.pos, fmt.Sprintf("+%d", points), rl.Gold)
+
+		// Replace it one for one; only evaporating bodies grow the star count
+		g.queueStarSpawn(star.pos)
+	}
+	g.starList = newStarList
+}
+
+// Replace an evaporated body with new stars, thrown out from where it was
+func (g *Game) respawnStars(origin rl.Vector2) {
+	for range g.starMultiplier {
+		g.queueStarSpawn(origin)
+	}
+}
+
+// The play field's size as a vector
+func (g *Game) screenSize() rl.Vector2 {
+	return rl.Vector2{X: float32(g.width), Y: float32(g.height)}
+}
+
 func (g *Game) generateRandomStar() Star {
-	return initStar(g, rl.Vector2{X: float32(rand.Intn(WindowWidth-40) + 20), Y: float32(rand.Intn(WindowHeight-40) + 20)}, 5)
+	return initStar(g, g.findSpawnPoint(starSpawnRules), 5)
 }
 
 func (g *Game) createNewAsteroid() {
-	// Determine side first
-	side := rand.Intn(4)
-	initialVelocity := rl.Vector2{}
-	directionOfFreeSide := rand.Float32() - 0.5
-	if directionOfFreeSide < 0 {
-		directionOfFreeSide = -1
-	} else {
-		directionOfFreeSide = 1
-	}
-	pos := rl.Vector2{}
-	velocityScale := rand.Float32() * 20.0
-
-	switch side {
-	case 0:
-		// Top side
-		pos.X = float32(rand.Intn(WindowWidth-80) + 40)
-		pos.Y = float32(WindowHeight - 20)
-		initialVelocity.X = rand.Float32() * directionOfFreeSide * velocityScale
-		initialVelocity.Y = rand.Float32() * velocityScale
-	case 1:
-		// Right side
-		pos.X = float32(WindowWidth + 20)
-		pos.Y = float32(rand.Intn(WindowHeight-80) + 40)
-		initialVelocity.X = rand.Float32() * -velocityScale
-		initialVelocity.Y = rand.Float32() * directionOfFreeSide * velocityScale
-	case 2:
-		// Bottom side
-		pos.X = float32(rand.Intn(WindowWidth-80) + 40)
-		pos.Y = float32(WindowHeight + 20)
-		initialVelocity.X = rand.Float32() * directionOfFreeSide * velocityScale
-		initialVelocity.Y = rand.Float32() * -velocityScale
-	case 3:
-		// Left side
-		pos.X = float32(WindowWidth - 20)
-		pos.Y = float32(rand.Intn(WindowHeight-80) + 40)
-		initialVelocity.X = rand.Float32() * velocityScale
-		initialVelocity.Y = rand.Float32() * directionOfFreeSide * velocityScale
-	}
-
-	g.asteroidList = append(g.asteroidList, initAsteroid(g, pos, 10.0, initialVelocity))
-	fmt.Println(len(g.asteroidList))
+	// Aim at a random living ship
+	living := []*Ship{}
+	for i := range g.ships {
+		if !g.ships[i].isDead {
+			living = append(living, &g.ships[i])
+		}
+	}
+	target := &g.ships[0]
+	if len(living) > 0 {
+		target = living[g.rng.Intn(len(living))]
+	}
+
+	pattern := chooseAsteroidPattern(g.rng, asteroidPatterns, g.elapsedTicks, len(g.blackHoleList))
+	launches := pattern.generate(g.rng, g.screenSize(), target.pos, target.currentVelocity(), g.blackHoleList)
+	for range SPAWN_ATTEMPTS {
+		if g.isSafeAsteroidPattern(launches) {
+			break
+		}
+		launches = pattern.generate(g.rng, g.screenSize(), target.pos, target.currentVelocity(), g.blackHoleList)
+	}
+
+	// Rocks that are still unfair after the retries are dropped, never slowed
+	for _, launch := range launches {
+		if g.isSafeAsteroidLaunch(launch.pos, launch.velocity) {
+			g.queueAsteroidSpawn(launch)
+		}
+	}
 	g.asteroidCountdownRange = rl.Vector2{
 		X: float32(math.Max(20, float64(g.asteroidCountdownRange.X)-10)),
 		Y: float32(math.Max(40, float64(g.asteroidCountdownRange.Y)-10)),
 	}
-	g.asteroidCountdown = int32(rand.Intn(int(g.asteroidCountdownRange.Y)) + int(g.asteroidCountdownRange.X))
+	g.asteroidCountdown = int32(g.rng.Intn(int(g.asteroidCountdownRange.Y)) + int(g.asteroidCountdownRange.X))
 }
 
 func (g *Game) createNewExplosion(p rl.Vector2, e int32) {
 	g.explosionClusterList = append(g.explosionClusterList, initExplosionCluster(g, p, e))
 }
+
+// Drive the shared engine sound from the loudest living ship, sputtering if its tank is nearly dry
+func (g *Game) updateEngineSound() {
+	if g.headless {
+		return
+	}
+	var loudest *Ship
+	for i := range g.ships {
+		ship := &g.ships[i]
+		if !ship.isDead && (loudest == nil || ship.enginePower() > loudest.enginePower()) {
+			loudest = ship
+		}
+	}
+
+	if loudest == nil || loudest.enginePower() <= 0 {
+		g.mixer.stop(ENGINE_SOUND)
+		return
+	}
+
+	// Square root so a light touch on the throttle is still audible
+	volume := float32(math.Sqrt(loudest.enginePower()))
+	pitch := float32(1)
+	if loudest.isLowOnFuel() {
+		if loudest.sputterCounter%20 < 7 {
+			volume *= 0.1
+		}
+		pitch = 0.8
+	}
+	if g.mixer.playing(ENGINE_SOUND, g.engineVoice) {
+		g.mixer.adjust(ENGINE_SOUND, g.engineVoice, volume, pitch)
+	} else {
+		g.engineVoice = g.mixer.play(ENGINE_SOUND, volume, pitch)
+	}
+}
@@ -0,0 +1,259 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+const GYM_MAX_LINE int = 1 << 20
+
+// How each step's reward is built; any field left out of a reset keeps its default
+type RewardConfig struct {
+	Survival         float32 `json:"survival"`           // per tick alive
+	NearMiss         float32 `json:"near_miss"`          // per asteroid that passes close without hitting
+	NearMissDistance float32 `json:"near_miss_distance"` // gap between hulls that counts as close
+	Score            float32 `json:"score"`              // per point of game score gained
+	Death            float32 `json:"death"`              // once, on dying
+}
+
+var defaultRewardConfig = RewardConfig{
+	Survival:         0.01,
+	NearMiss:         0.5,
+	NearMissDistance: 30,
+	Score:            0,
+	Death:            -10,
+}
+
+// One line from the agent
+type GymRequest struct {
+	Command  string          `json:"cmd"` // "reset", "step" or "close"
+	Seed     int64           `json:"seed"`
+	Ship     int             `json:"ship"`
+	MaxTicks int32           `json:"max_ticks"` // 0 for no limit
+	Repeat   int             `json:"repeat"`    // ticks to hold the action for, at least 1
+	Reward   json.RawMessage `json:"reward"`    // partial RewardConfig
+	Action   GymAction       `json:"action"`
+}
+
+// Controls chosen by the agent, each in [-1, 1]
+type GymAction struct {
+	Turn      float32 `json:"turn"`
+	Throttle  float32 `json:"throttle"`
+	Strafe    float32 `json:"strafe"`
+	Abilities []bool  `json:"abilities"`
+}
+
+type GymInfo struct {
+	Score      int32 `json:"score"`
+	Tick       int32 `json:"tick"`
+	NearMisses int   `json:"near_misses"`
+	Truncated  bool  `json:"truncated"` // stopped by max_ticks rather than death
+}
+
+// One line back to the agent
+type GymResponse struct {
+	Observation *Observation `json:"observation,omitempty"`
+	Reward      float32      `json:"reward"`
+	Done        bool         `json:"done"`
+	Info        *GymInfo     `json:"info,omitempty"`
+	Error       string       `json:"error,omitempty"`
+}
+
+// A solo game wrapped for reinforcement learning
+type GymEnv struct {
+	game       Game
+	reward     RewardConfig
+	maxTicks   int32
+	nearMissed map[int32]bool // asteroids already rewarded this run
+	closeBy    map[int32]bool // asteroids inside the near-miss distance last tick
+	nearMisses int
+	lastScore  int32
+	done       bool
+	started    bool
+}
+
+func initGymEnv() GymEnv {
+	return GymEnv{game: initHeadlessGame()}
+}
+
+func (e *GymEnv) reset(r GymRequest) GymResponse {
+	if r.Ship < 0 || r.Ship >= len(shipRoster) {
+		return GymResponse{Error: fmt.Sprintf("ship must be from 0 to %d", len(shipRoster)-1)}
+	}
+
+	e.reward = defaultRewardConfig
+	if len(r.Reward) > 0 {
+		if err := json.Unmarshal(r.Reward, &e.reward); err != nil {
+			return GymResponse{Error: fmt.Sprintf("bad reward config: %v", err)}
+		}
+	}
+	e.maxTicks = r.MaxTicks
+	e.nearMissed = map[int32]bool{}
+	e.closeBy = map[int32]bool{}
+	e.nearMisses = 0
+	e.done = false
+	e.started = true
+
+	g := &e.game
+	g.mode = Solo
+	g.selectedShips[0] = r.Ship
+	g.seed = r.Seed
+	g.reloadGameComponents()
+	g.gameState = Play
+	e.lastScore = g.score
+
+	o := g.observe(0)
+	return GymResponse{Observation: &o, Info: e.info(false)}
+}
+
+func (e *GymEnv) step(r GymRequest) GymResponse {
+	if !e.started {
+		return GymResponse{Error: "reset before stepping"}
+	}
+	if e.done {
+		return GymResponse{Error: "episode is over; reset to start another"}
+	}
+
+	g := &e.game
+	controls := r.Action.controls()
+	reward := float32(0)
+	truncated := false
+	for range max(1, r.Repeat) {
+		g.controls[0] = controls
+		// Only the first tick of a held action fires abilities
+		controls.abilities = [MAX_ABILITIES]bool{}
+		g.update()
+
+		ship := &g.ships[0]
+		if ship.isDead {
+			reward += e.reward.Death
+			e.done = true
+			break
+		}
+		reward += e.reward.Survival
+		reward += e.reward.NearMiss * float32(e.countNearMisses())
+		reward += e.reward.Score * float32(g.score-e.lastScore)
+		e.lastScore = g.score
+
+		if e.maxTicks > 0 && g.elapsedTicks >= e.maxTicks {
+			e.done = true
+			truncated = true
+			break
+		}
+	}
+
+	o := g.observe(0)
+	return GymResponse{Observation: &o, Reward: reward, Done: e.done, Info: e.info(truncated)}
+}
+
+// Asteroids that came within the near-miss distance and left it this tick, with the ship still alive
+func (e *GymEnv) countNearMisses() int {
+	ship := &e.game.ships[0]
+	count := 0
+	closeBy := map[int32]bool{}
+	for _, asteroid := range e.game.asteroidList {
+		if !asteroid.isAlive || e.nearMissed[asteroid.id] {
+			continue
+		}
+		circle := asteroid.getCollisionCircle()
+		gap := rl.Vector2Distance(ship.pos, rl.Vector2{X: circle.X, Y: circle.Y}) - ship.radius - circle.Z
+		if gap < e.reward.NearMissDistance {
+			closeBy[asteroid.id] = true
+		} else if e.closeBy[asteroid.id] {
+			e.nearMissed[asteroid.id] = true
+			count += 1
+		}
+	}
+	// Rocks destroyed while close by drop out without paying
+	e.closeBy = closeBy
+	e.nearMisses += count
+	return count
+}
+
+func (e *GymEnv) info(truncated bool) *GymInfo {
+	return &GymInfo{Score: e.game.score, Tick: e.game.elapsedTicks, NearMisses: e.nearMisses, Truncated: truncated}
+}
+
+func (a GymAction) controls() ShipControls {
+	c := ShipControls{
+		turn:     max(-1, min(1, a.Turn)),
+		throttle: max(-1, min(1, a.Throttle)),
+		strafe:   max(-1, min(1, a.Strafe)),
+	}
+	for i := range min(len(a.Abilities), MAX_ABILITIES) {
+		c.abilities[i] = a.Abilities[i]
+	}
+	return c
+}
+
+// Answer JSON-lines requests until the agent closes the stream or says "close"
+func serveGym(r io.Reader, w io.Writer) error {
+	env := initGymEnv()
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 4096), GYM_MAX_LINE)
+	encoder := json.NewEncoder(w)
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		var request GymRequest
+		var response GymResponse
+		if err := json.Unmarshal([]byte(line), &request); err != nil {
+			response = GymResponse{Error: fmt.Sprintf("bad request: %v", err)}
+		} else {
+			switch request.Command {
+			case "reset":
+				response = env.reset(request)
+			case "step":
+				response = env.step(request)
+			case "close":
+				return nil
+			default:
+				response = GymResponse{Error: fmt.Sprintf("unknown cmd %q (use reset, step or close)", request.Command)}
+			}
+		}
+		if err := encoder.Encode(response); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// Serve the environment on stdin/stdout ("stdio") or to TCP clients one at a time ("tcp:ADDRESS")
+func runGym(endpoint string) error {
+	if endpoint == "stdio" {
+		return serveGym(os.Stdin, os.Stdout)
+	}
+
+	address, ok := strings.CutPrefix(endpoint, "tcp:")
+	if !ok {
+		return fmt.Errorf("gym endpoint must be stdio or tcp:ADDRESS, not %q", endpoint)
+	}
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return err
+	}
+	defer listener.Close()
+	fmt.Fprintf(os.Stderr, "gym listening on %s\n", listener.Addr())
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return err
+		}
+		if err := serveGym(conn, conn); err != nil {
+			fmt.Fprintf(os.Stderr, "gym connection ended: %v\n", err)
+		}
+		conn.Close()
+	}
+}
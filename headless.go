@@ -0,0 +1,77 @@
+package main
+
+const ENTITY_SAMPLE_TICKS int32 = 600
+
+// How much is on the field at one moment of a run
+type EntityCounts struct {
+	Tick       int32 `json:"tick"`
+	BlackHoles int   `json:"black_holes"`
+	WhiteHoles int   `json:"white_holes"`
+	Wormholes  int   `json:"wormholes"`
+	Stars      int   `json:"stars"`
+	Asteroids  int   `json:"asteroids"`
+}
+
+// Outcome of one headless run
+type RunResult struct {
+	Seed          int64          `json:"seed"`
+	SurvivalTicks int32          `json:"survival_ticks"`
+	Score         int32          `json:"score"`
+	DeathCause    string         `json:"death_cause"`
+	Samples       []EntityCounts `json:"samples,omitempty"` // every ENTITY_SAMPLE_TICKS while alive
+}
+
+// A game with no window or audio, for bots and batch runs
+func initHeadlessGame() Game {
+	shipSprites := []*Sprite{}
+	for range shipRoster {
+		shipSprites = append(shipSprites, emptySprite())
+	}
+	return Game{
+		gameState:       Start,
+		difficulty:      Normal,
+		headless:        true,
+		width:           DEFAULT_WINDOW_WIDTH,
+		height:          DEFAULT_WINDOW_HEIGHT,
+		highScores:      HighScores{},
+		shipSprites:     shipSprites,
+		asteroidSprite:  emptySprite(),
+		blackHoleSprite: emptySprite(),
+		starSprite:      emptySprite(),
+	}
+}
+
+// Play one seeded run to the end as fast as the simulation allows; maxTicks <= 0 means no limit
+func (g *Game) runHeadless(seed int64, maxTicks int32) RunResult {
+	g.seed = seed
+	g.reloadGameComponents()
+	g.gameState = Play
+
+	result := RunResult{Seed: seed, Samples: []EntityCounts{}}
+	for g.gameState == Play && (maxTicks <= 0 || g.elapsedTicks < maxTicks) {
+		for i := range g.ships {
+			g.controls[i] = g.playerControls(i)
+		}
+		g.update()
+		if g.anyShipAlive() {
+			result.SurvivalTicks = g.elapsedTicks
+			if g.elapsedTicks%ENTITY_SAMPLE_TICKS == 0 {
+				result.Samples = append(result.Samples, g.countEntities())
+			}
+		}
+	}
+	result.Score = g.score
+	result.DeathCause = g.ships[0].deathCause.name()
+	return result
+}
+
+func (g *Game) countEntities() EntityCounts {
+	return EntityCounts{
+		Tick:       g.elapsedTicks,
+		BlackHoles: len(g.blackHoleList),
+		WhiteHoles: len(g.whiteHoleList),
+		Wormholes:  len(g.wormholeList),
+		Stars:      len(g.starList),
+		Asteroids:  len(g.asteroidList),
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+const HIGH_SCORE_FILE string = "highscores.json"
+
+// Best score for each ship, keyed by ship name
+type HighScores map[string]int32
+
+// Where a file of saved state lives in the user's config directory
+func userConfigPath(file string) (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "black-hole-bounce", file), nil
+}
+
+func highScorePath() (string, error) {
+	return userConfigPath(HIGH_SCORE_FILE)
+}
+
+// Load the saved high scores, starting fresh if there are none
+func loadHighScores() HighScores {
+	scores := HighScores{}
+	path, err := highScorePath()
+	if err != nil {
+		return scores
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return scores
+	}
+	if err := json.Unmarshal(data, &scores); err != nil {
+		fmt.Fprintf(os.Stderr, "ignoring unreadable high scores in %s: %v\n", path, err)
+		return HighScores{}
+	}
+	return scores
+}
+
+func (h HighScores) save() error {
+	path, err := highScorePath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(h, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// Record a score for a ship, returning whether it's a new best
+func (h HighScores) record(ship string, score int32) bool {
+	if score <= h[ship] {
+		return false
+	}
+	h[ship] = score
+	return true
+}
@@ -1,6 +1,7 @@
 package main
 
+import "os"
+
 func main() {
-	game := initGame()
-	game.run()
+	os.Exit(runCLI(os.Args[1:], os.Stdout, os.Stderr))
 }
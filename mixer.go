@@ -0,0 +1,300 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+const AUDIO_SETTINGS_FILE string = "audio.json"
+const VOLUME_STEP float32 = 0.1
+const DUCK_LEVEL float32 = 0.3
+const DUCK_HOLD_SECONDS float32 = 2
+const DUCK_ATTACK_SECONDS float32 = 0.15
+const DUCK_RELEASE_SECONDS float32 = 1.5
+
+const EXPLOSION_SOUND string = "sound/explosion.wav"
+const ENGINE_SOUND string = "sound/engine.wav"
+
+type AudioBus int
+
+const (
+	MasterBus AudioBus = iota
+	MusicBus
+	EffectsBus
+)
+
+func (b AudioBus) name() string {
+	switch b {
+	case MusicBus:
+		return "Music"
+	case EffectsBus:
+		return "Effects"
+	default:
+		return "Master"
+	}
+}
+
+// Volume of each bus from 0 to 1; master scales the other two
+type AudioSettings struct {
+	Master  float32 `json:"master"`
+	Music   float32 `json:"music"`
+	Effects float32 `json:"effects"`
+}
+
+var defaultAudioSettings = AudioSettings{Master: 1, Music: 0.6, Effects: 0.8}
+
+func (s *AudioSettings) bus(b AudioBus) *float32 {
+	switch b {
+	case MusicBus:
+		return &s.Music
+	case EffectsBus:
+		return &s.Effects
+	default:
+		return &s.Master
+	}
+}
+
+// Load the saved volumes, falling back to the defaults
+func loadAudioSettings(log io.Writer) AudioSettings {
+	settings := defaultAudioSettings
+	path, err := userConfigPath(AUDIO_SETTINGS_FILE)
+	if err != nil {
+		return settings
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return settings
+	}
+	if err := json.Unmarshal(data, &settings); err != nil {
+		fmt.Fprintf(log, "ignoring unreadable audio settings in %s: %v\n", path, err)
+		return defaultAudioSettings
+	}
+	for _, b := range []AudioBus{MasterBus, MusicBus, EffectsBus} {
+		volume := settings.bus(b)
+		*volume = max(0, min(1, *volume))
+	}
+	return settings
+}
+
+func (s AudioSettings) save() error {
+	path, err := userConfigPath(AUDIO_SETTINGS_FILE)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// How one sound effect is mixed
+type SoundMix struct {
+	gain   float32 // evens out how loud the files are recorded
+	voices int     // most copies that can play at once; a new one cuts off the oldest
+}
+
+var soundMixes = map[string]SoundMix{
+	EXPLOSION_SOUND: {gain: 0.7, voices: 4},
+	ENGINE_SOUND:    {gain: 0.5, voices: 1},
+}
+
+// Copies of a sound that play over each other: the sound itself, then aliases sharing its samples
+type SoundVoices struct {
+	mix     SoundMix
+	base    *rl.Sound
+	aliases []rl.Sound
+	levels  []float32 // volume each voice was asked for, before the buses
+	next    int       // voice to cut off when they're all busy
+}
+
+func (v *SoundVoices) voice(i int) rl.Sound {
+	if i == 0 {
+		return *v.base
+	}
+	return v.aliases[i-1]
+}
+
+// Routes music and sound effects through master, music and effects buses
+type Mixer struct {
+	assets   *AssetManager
+	music    *MusicPlayer
+	settings AudioSettings // in effect now, including any session overrides
+	saved    AudioSettings // as stored in the settings file
+	sounds   map[string]*SoundVoices
+	duck     float32 // music level while ducked, 1 when not
+	duckHold float32 // seconds left before the music comes back up
+	log      io.Writer
+}
+
+func initMixer(assets *AssetManager, music *MusicPlayer, log io.Writer) *Mixer {
+	settings := loadAudioSettings(log)
+	m := &Mixer{
+		assets:   assets,
+		music:    music,
+		settings: settings,
+		saved:    settings,
+		sounds:   map[string]*SoundVoices{},
+		duck:     1,
+		log:      log,
+	}
+	for name, mix := range soundMixes {
+		m.sounds[name] = &SoundVoices{
+			mix:    mix,
+			base:   assets.acquireSound(name),
+			levels: make([]float32, max(1, mix.voices)),
+		}
+	}
+	// Aliases share the samples of the sound they copy, so drop them before it's replaced
+	assets.beforeSoundReload = m.dropAliases
+	m.music.setVolume(m.musicVolume())
+	return m
+}
+
+// Play a sound effect at a volume from 0 to 1, returning the voice it plays on
+func (m *Mixer) play(name string, volume float32, pitch float32) int {
+	v, ok := m.sounds[name]
+	if !ok {
+		fmt.Fprintf(m.log, "mixer: no sound %s\n", name)
+		return -1
+	}
+	m.ensureAliases(v)
+
+	voice := -1
+	for i := range v.levels {
+		if !rl.IsSoundPlaying(v.voice(i)) {
+			voice = i
+			break
+		}
+	}
+	if voice < 0 {
+		voice = v.next
+		v.next = (v.next + 1) % len(v.levels)
+		rl.StopSound(v.voice(voice))
+	}
+
+	m.adjust(name, voice, volume, pitch)
+	rl.PlaySound(v.voice(voice))
+	return voice
+}
+
+// Change the volume and pitch of a voice that's playing
+func (m *Mixer) adjust(name string, voice int, volume float32, pitch float32) {
+	v, ok := m.sounds[name]
+	if !ok || voice < 0 || voice >= len(v.levels) {
+		return
+	}
+	m.ensureAliases(v)
+	v.levels[voice] = max(0, min(1, volume))
+	rl.SetSoundVolume(v.voice(voice), m.effectVolume(v, voice))
+	rl.SetSoundPitch(v.voice(voice), pitch)
+}
+
+func (m *Mixer) playing(name string, voice int) bool {
+	v, ok := m.sounds[name]
+	if !ok || voice < 0 || voice >= len(v.levels) {
+		return false
+	}
+	m.ensureAliases(v)
+	return rl.IsSoundPlaying(v.voice(voice))
+}
+
+// Silence every voice of a sound
+func (m *Mixer) stop(name string) {
+	v, ok := m.sounds[name]
+	if !ok {
+		return
+	}
+	m.ensureAliases(v)
+	for i := range v.levels {
+		rl.StopSound(v.voice(i))
+	}
+}
+
+func (m *Mixer) ensureAliases(v *SoundVoices) {
+	for len(v.aliases) < len(v.levels)-1 {
+		v.aliases = append(v.aliases, rl.LoadSoundAlias(*v.base))
+	}
+}
+
+func (m *Mixer) dropAliases(name string) {
+	v, ok := m.sounds[name]
+	if !ok {
+		return
+	}
+	for _, alias := range v.aliases {
+		rl.StopSound(alias)
+		rl.UnloadSoundAlias(alias)
+	}
+	v.aliases = nil
+}
+
+func (m *Mixer) effectVolume(v *SoundVoices, voice int) float32 {
+	return v.levels[voice] * v.mix.gain * m.settings.Effects * m.settings.Master
+}
+
+func (m *Mixer) musicVolume() float32 {
+	return m.settings.Music * m.settings.Master * m.duck
+}
+
+// Pull the music down for a moment, e.g. when a ship is destroyed
+func (m *Mixer) duckMusic() {
+	m.duckHold = DUCK_HOLD_SECONDS
+}
+
+// Ease the music duck and feed the music stream; call once a frame
+func (m *Mixer) update(dt float32) {
+	if m.duckHold > 0 {
+		m.duckHold -= dt
+		m.duck = max(DUCK_LEVEL, m.duck-(1-DUCK_LEVEL)*dt/DUCK_ATTACK_SECONDS)
+	} else {
+		m.duck = min(1, m.duck+(1-DUCK_LEVEL)*dt/DUCK_RELEASE_SECONDS)
+	}
+	m.music.setVolume(m.musicVolume())
+	m.music.update(dt)
+}
+
+// Set a bus's volume for this session without saving it
+func (m *Mixer) setVolume(b AudioBus, volume float32) {
+	*m.settings.bus(b) = max(0, min(1, volume))
+	m.music.setVolume(m.musicVolume())
+	for _, v := range m.sounds {
+		for i := range v.aliases {
+			rl.SetSoundVolume(v.aliases[i], m.effectVolume(v, i+1))
+		}
+		rl.SetSoundVolume(*v.base, m.effectVolume(v, 0))
+	}
+}
+
+// Step a bus's volume up, wrapping from full back to silent, and remember it; other buses keep their saved levels
+func (m *Mixer) cycleVolume(b AudioBus) {
+	next := *m.settings.bus(b) + VOLUME_STEP
+	if next > 1+VOLUME_STEP/2 {
+		next = 0
+	}
+	m.setVolume(b, next)
+	*m.saved.bus(b) = *m.settings.bus(b)
+	if err := m.saved.save(); err != nil {
+		fmt.Fprintf(m.log, "couldn't save audio settings: %v\n", err)
+	}
+}
+
+func (m *Mixer) close() {
+	m.assets.beforeSoundReload = nil
+	for name, v := range m.sounds {
+		m.dropAliases(name)
+		rl.StopSound(*v.base)
+		m.assets.releaseSound(name)
+	}
+	m.sounds = map[string]*SoundVoices{}
+}
@@ -0,0 +1,316 @@
+package main
+
+import (
+	"fmt"
+	"math"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+const MAX_PLAYERS int = 2
+const COOP_LIVES int32 = 3
+const REVIVE_RADIUS float32 = 40
+const REVIVE_TICKS int32 = 90
+const BUMP_IMPULSE float32 = 2.5
+
+type GameMode int
+
+const (
+	Solo GameMode = iota
+	Coop
+	Versus
+)
+
+func (m GameMode) name() string {
+	switch m {
+	case Coop:
+		return "Co-op"
+	case Versus:
+		return "Versus"
+	default:
+		return "Solo"
+	}
+}
+
+func (m GameMode) next() GameMode {
+	return (m + 1) % 3
+}
+
+func (m GameMode) playerCount() int {
+	if m == Solo {
+		return 1
+	}
+	return MAX_PLAYERS
+}
+
+// Keyboard layout for one player
+type KeyBindings struct {
+	left        int32
+	right       int32
+	up          int32
+	down        int32
+	strafeLeft  int32
+	strafeRight int32
+	abilities   [MAX_ABILITIES]int32
+}
+
+// Layout when playing alone
+var soloBindings = KeyBindings{
+	left:        rl.KeyLeft,
+	right:       rl.KeyRight,
+	up:          rl.KeyUp,
+	down:        rl.KeyDown,
+	strafeLeft:  rl.KeyZ,
+	strafeRight: rl.KeyX,
+	abilities:   [MAX_ABILITIES]int32{rl.KeyQ, rl.KeyW, rl.KeyE, rl.KeyR},
+}
+
+// Layouts for two players sharing a keyboard
+var playerBindings = [MAX_PLAYERS]KeyBindings{
+	{
+		left:        rl.KeyA,
+		right:       rl.KeyD,
+		up:          rl.KeyW,
+		down:        rl.KeyS,
+		strafeLeft:  rl.KeyQ,
+		strafeRight: rl.KeyE,
+		abilities:   [MAX_ABILITIES]int32{rl.KeyOne, rl.KeyTwo, rl.KeyThree, rl.KeyFour},
+	},
+	{
+		left:        rl.KeyLeft,
+		right:       rl.KeyRight,
+		up:          rl.KeyUp,
+		down:        rl.KeyDown,
+		strafeLeft:  rl.KeyComma,
+		strafeRight: rl.KeyPeriod,
+		abilities:   [MAX_ABILITIES]int32{rl.KeySeven, rl.KeyEight, rl.KeyNine, rl.KeyZero},
+	},
+}
+
+var playerColors = [MAX_PLAYERS]rl.Color{rl.SkyBlue, rl.Pink}
+
+func (g *Game) keyBindings(player int) KeyBindings {
+	// Solo players and each end of a network game have the keyboard to themselves
+	if g.mode == Solo || g.net != nil {
+		return soloBindings
+	}
+	return playerBindings[player]
+}
+
+// Starting position for each player
+func (g *Game) spawnPosition(player int) rl.Vector2 {
+	center := rl.Vector2{X: float32(g.width) / 2, Y: float32(g.height) / 2}
+	if g.mode == Solo {
+		return center
+	}
+	return rl.Vector2Add(center, rl.Vector2{X: float32(player*2-1) * 100, Y: 0})
+}
+
+func (g *Game) anyShipAlive() bool {
+	for _, ship := range g.ships {
+		if !ship.isDead {
+			return true
+		}
+	}
+	return false
+}
+
+// Whether the run has been decided
+func (g *Game) isRunOver() bool {
+	if g.mode == Versus {
+		living := 0
+		for _, ship := range g.ships {
+			if !ship.isDead {
+				living += 1
+			}
+		}
+		return living <= 1
+	}
+	return !g.anyShipAlive()
+}
+
+// Settle the result as soon as the run is over, so nothing during the restart countdown changes it
+func (g *Game) decideRun() {
+	g.runDecided = true
+	g.winner = -1
+	if g.mode != Versus {
+		return
+	}
+	for i, ship := range g.ships {
+		if !ship.isDead {
+			g.winner = i
+		}
+	}
+}
+
+// The ship closest to a point, or nil if every ship is dead
+func (g *Game) nearestLivingShip(p rl.Vector2) *Ship {
+	var nearest *Ship
+	for i := range g.ships {
+		ship := &g.ships[i]
+		if ship.isDead {
+			continue
+		}
+		if nearest == nil || rl.Vector2Distance(p, ship.pos) < rl.Vector2Distance(p, nearest.pos) {
+			nearest = ship
+		}
+	}
+	return nearest
+}
+
+// Add points for a player; unattributed points (player < 0) go to the team, or nobody in versus
+func (g *Game) awardPoints(player int, points int32) {
+	if g.mode == Versus {
+		if player >= 0 {
+			g.ships[player].score += points
+		}
+		return
+	}
+	g.score += points
+}
+
+// In co-op, a living partner hovering near a wreck brings it back, at the cost of a shared life
+func (g *Game) reviveWrecks() {
+	if g.mode != Coop || g.lives <= 0 {
+		return
+	}
+	for i := range g.ships {
+		wreck := &g.ships[i]
+		if !wreck.isDead || !g.isOnScreen(wreck.pos) {
+			continue
+		}
+
+		// A wreck inside a black hole would only die again the moment it came back
+		if g.insideDeathRadius(wreck.pos, wreck.radius) {
+			wreck.reviveProgress = 0
+			continue
+		}
+
+		rescuerNearby := false
+		for j, ship := range g.ships {
+			if j != i && !ship.isDead && rl.Vector2Distance(ship.pos, wreck.pos) < REVIVE_RADIUS {
+				rescuerNearby = true
+			}
+		}
+		if !rescuerNearby {
+			wreck.reviveProgress = 0
+			continue
+		}
+
+		wreck.reviveProgress += 1
+		if wreck.reviveProgress >= REVIVE_TICKS {
+			wreck.revive()
+			g.lives -= 1
+			g.addCallout(wreck.pos, "Revived!", rl.Green)
+		}
+	}
+}
+
+// Whether a circle touches any black hole's death radius
+func (g *Game) insideDeathRadius(p rl.Vector2, r float32) bool {
+	for _, blackHole := range g.blackHoleList {
+		if rl.CheckCollisionCircles(p, r, blackHole.pos, blackHole.deathRadius) {
+			return true
+		}
+	}
+	return false
+}
+
+// In versus, ships bounce off each other
+func (g *Game) bumpShips() {
+	if g.mode != Versus {
+		return
+	}
+	for i := range g.ships {
+		for j := i + 1; j < len(g.ships); j++ {
+			a, b := &g.ships[i], &g.ships[j]
+			if a.isDead || b.isDead || !rl.CheckCollisionCircles(a.pos, a.radius*2, b.pos, b.radius*2) {
+				continue
+			}
+
+			// Swap velocities along the line between the ships and shove them apart
+			normal := rl.Vector2Normalize(rl.Vector2Subtract(b.pos, a.pos))
+			if rl.Vector2Length(normal) == 0 {
+				normal = rl.Vector2{X: 1, Y: 0}
+			}
+			relative := rl.Vector2DotProduct(rl.Vector2Subtract(a.currentVelocity(), b.currentVelocity()), normal)
+			impulse := max(relative, 0) + BUMP_IMPULSE
+			a.velocity = rl.Vector2Subtract(a.velocity, rl.Vector2Scale(normal, impulse))
+			b.velocity = rl.Vector2Add(b.velocity, rl.Vector2Scale(normal, impulse))
+		}
+	}
+}
+
+// Per-player score, fuel and ability panels
+func (g *Game) renderPlayerHUD() {
+	if g.mode == Solo {
+		ship := &g.ships[0]
+		rl.DrawText(fmt.Sprintf("Score: %d", g.score), 10, 10, 40, rl.RayWhite)
+		if g.fuelMode {
+			ship.renderFuelGauge(10, 60)
+		}
+		ship.renderAbilityIcons(10)
+		return
+	}
+
+	if g.mode == Coop {
+		text := fmt.Sprintf("Team score: %d   Lives: %d", g.score, g.lives)
+		rl.DrawText(text, int32(g.width)/2-rl.MeasureText(text, 40)/2, 10, 40, rl.RayWhite)
+	}
+
+	for i := range g.ships {
+		ship := &g.ships[i]
+		x := int32(10)
+		if i == 1 {
+			x = int32(g.width) - 320
+		}
+		label := fmt.Sprintf("P%d", i+1)
+		if g.mode == Versus {
+			label = fmt.Sprintf("P%d: %d", i+1, ship.score)
+		}
+		if ship.isDead {
+			label += " (wrecked)"
+		}
+		rl.DrawText(label, x, 10, 40, playerColors[i])
+		if g.fuelMode {
+			ship.renderFuelGauge(x, 60)
+		}
+		ship.renderAbilityIcons(x)
+	}
+}
+
+// Results for the Restart screen
+func (g *Game) renderResults() {
+	switch g.mode {
+	case Versus:
+		title := "Draw!"
+		if g.winner >= 0 {
+			title = fmt.Sprintf("Player %d wins!", g.winner+1)
+		}
+		rl.DrawText(title, int32(g.width)/2-rl.MeasureText(title, 64)/2, 230, 64, rl.Gold)
+		scores := fmt.Sprintf("P1: %d   P2: %d", g.ships[0].score, g.ships[1].score)
+		rl.DrawText(scores, int32(g.width)/2-rl.MeasureText(scores, 64)/2, 300, 64, rl.RayWhite)
+	case Coop:
+		text := fmt.Sprintf("Team Score: %d", g.score)
+		rl.DrawText(text, int32(g.width)/2-rl.MeasureText(text, 64)/2, 300, 64, rl.RayWhite)
+	default:
+		rl.DrawText(fmt.Sprintf("Final Score: %d", g.score), 620, 300, 64, rl.RayWhite)
+		if g.newHighScore {
+			rl.DrawText(fmt.Sprintf("New best for the %s!", shipRoster[g.selectedShips[0]].name), 600, 250, 40, rl.Gold)
+		}
+	}
+}
+
+// Ring showing revive progress over a wreck
+func (s *Ship) renderWreck() {
+	if s.game.mode != Coop || !s.game.isOnScreen(s.pos) || s.game.insideDeathRadius(s.pos, s.radius) {
+		return
+	}
+	rl.DrawCircleLines(int32(s.pos.X), int32(s.pos.Y), REVIVE_RADIUS, rl.Fade(rl.Green, 0.4))
+	if s.reviveProgress > 0 {
+		progress := float32(s.reviveProgress) / float32(REVIVE_TICKS)
+		rl.DrawRing(s.pos, REVIVE_RADIUS-3, REVIVE_RADIUS, -90, -90+360*progress, 48, rl.Green)
+	}
+	pulse := float32(math.Abs(math.Sin(rl.GetTime() * 3)))
+	rl.DrawCircleV(s.pos, 4, rl.Fade(rl.Gray, 0.5+0.5*pulse))
+}
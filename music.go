@@ -0,0 +1,198 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"path"
+	"strings"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+const MUSIC_CROSSFADE_SECONDS float32 = 2.5
+const SILENT_TRACK_SECONDS float32 = 10
+
+// What the music should feel like; each mood has its own playlist
+type MusicMood int
+
+const (
+	MenuMood MusicMood = iota
+	PlayMood
+	GameOverMood
+)
+
+// Tracks for each mood, played in order and looped; a single track loops on its own
+var musicPlaylists = [][]string{
+	MenuMood:     {"sound/music_menu.wav"},
+	PlayMood:     {"sound/music_play.wav"},
+	GameOverMood: {"sound/music_game_over.wav"},
+}
+
+func (g *Game) musicMood() MusicMood {
+	switch g.gameState {
+	case Play:
+		return PlayMood
+	case Restart:
+		return GameOverMood
+	default:
+		return MenuMood
+	}
+}
+
+// One streaming track, fading in or out
+type MusicVoice struct {
+	stream   rl.Music
+	data     []byte // raylib streams out of this buffer, so it has to outlive the stream; nil when streaming from a file
+	mood     MusicMood
+	track    int     // index in the mood's playlist
+	gain     float32 // crossfade position from 0 to 1
+	fadeRate float32 // gain change per second; negative while fading out
+	length   float32
+}
+
+// Streams the playlist for the current mood, crossfading whenever the mood or track changes
+type MusicPlayer struct {
+	assets  *AssetManager
+	volume  float32 // output level, set by the mixer
+	current *MusicVoice
+	fading  []*MusicVoice
+	warned  map[string]bool // tracks already reported as missing
+	log     io.Writer
+}
+
+func initMusicPlayer(assets *AssetManager, log io.Writer) *MusicPlayer {
+	p := &MusicPlayer{
+		assets: assets,
+		warned: map[string]bool{},
+		log:    log,
+	}
+	// Tracks stream in on demand, so check they exist up front for the startup report
+	checked := map[string]bool{}
+	for _, playlist := range musicPlaylists {
+		for _, name := range playlist {
+			if !checked[name] {
+				assets.loader.check(name, MusicAsset, "silence")
+				checked[name] = true
+			}
+		}
+	}
+	return p
+}
+
+// Switch to a mood's playlist, crossfading from whatever is playing; nothing changes if it's already the mood
+func (p *MusicPlayer) play(mood MusicMood) {
+	if p.current != nil && p.current.mood == mood {
+		return
+	}
+	p.crossfadeTo(mood, 0)
+}
+
+func (p *MusicPlayer) crossfadeTo(mood MusicMood, track int) {
+	if p.current != nil {
+		p.current.fadeRate = -1 / MUSIC_CROSSFADE_SECONDS
+		p.fading = append(p.fading, p.current)
+	}
+	p.current = p.startVoice(mood, track)
+}
+
+func (p *MusicPlayer) startVoice(mood MusicMood, track int) *MusicVoice {
+	playlist := musicPlaylists[mood]
+	name := playlist[track]
+	var stream rl.Music
+	var data []byte
+	var err error
+	if file, ok := p.assets.loader.overrideFile(name); ok {
+		// Decode straight from disk instead of holding the whole file in memory
+		stream = rl.LoadMusicStream(file)
+	} else if data, _, err = p.assets.loader.read(name); err == nil {
+		stream = rl.LoadMusicStreamFromMemory(strings.ToLower(path.Ext(name)), data, int32(len(data)))
+	}
+	if err == nil && !rl.IsMusicValid(stream) {
+		err = fmt.Errorf("can't decode it")
+	}
+	if err != nil {
+		if !p.warned[name] {
+			fmt.Fprintf(p.log, "music: can't play %s (%v), playing silence instead\n", name, err)
+			p.warned[name] = true
+		}
+		data = silentWAV(int(SILENT_TRACK_SECONDS * float32(PLACEHOLDER_SAMPLE_RATE)))
+		stream = rl.LoadMusicStreamFromMemory(".wav", data, int32(len(data)))
+	}
+
+	// A lone track loops seamlessly in the stream; playlists crossfade from one track to the next
+	stream.Looping = len(playlist) == 1
+	voice := &MusicVoice{
+		stream:   stream,
+		data:     data,
+		mood:     mood,
+		track:    track,
+		fadeRate: 1 / MUSIC_CROSSFADE_SECONDS,
+		length:   rl.GetMusicTimeLength(stream),
+	}
+	p.applyVolume(voice)
+	rl.PlayMusicStream(stream)
+	return voice
+}
+
+// Feed the audio streams and advance fades; call once a frame
+func (p *MusicPlayer) update(dt float32) {
+	if voice := p.current; voice != nil {
+		rl.UpdateMusicStream(voice.stream)
+		voice.gain = min(1, voice.gain+voice.fadeRate*dt)
+		p.applyVolume(voice)
+
+		// Start fading in the next track of the playlist as this one nears its end
+		playlist := musicPlaylists[voice.mood]
+		remaining := voice.length - rl.GetMusicTimePlayed(voice.stream)
+		if len(playlist) > 1 && remaining < MUSIC_CROSSFADE_SECONDS {
+			p.crossfadeTo(voice.mood, (voice.track+1)%len(playlist))
+		}
+	}
+
+	fading := []*MusicVoice{}
+	for _, voice := range p.fading {
+		rl.UpdateMusicStream(voice.stream)
+		voice.gain += voice.fadeRate * dt
+		if voice.gain <= 0 {
+			p.stopVoice(voice)
+			continue
+		}
+		p.applyVolume(voice)
+		fading = append(fading, voice)
+	}
+	p.fading = fading
+}
+
+// Equal-power curve, so a crossfade doesn't dip in the middle
+func (p *MusicPlayer) applyVolume(voice *MusicVoice) {
+	gain := float32(math.Sin(float64(max(0, voice.gain)) * math.Pi / 2))
+	rl.SetMusicVolume(voice.stream, p.volume*gain)
+}
+
+func (p *MusicPlayer) stopVoice(voice *MusicVoice) {
+	rl.StopMusicStream(voice.stream)
+	rl.UnloadMusicStream(voice.stream)
+	voice.data = nil
+}
+
+func (p *MusicPlayer) setVolume(volume float32) {
+	p.volume = max(0, min(1, volume))
+	if p.current != nil {
+		p.applyVolume(p.current)
+	}
+	for _, voice := range p.fading {
+		p.applyVolume(voice)
+	}
+}
+
+func (p *MusicPlayer) close() {
+	if p.current != nil {
+		p.stopVoice(p.current)
+		p.current = nil
+	}
+	for _, voice := range p.fading {
+		p.stopVoice(voice)
+	}
+	p.fading = []*MusicVoice{}
+}
@@ -0,0 +1,454 @@
+package main
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"net"
+	"os"
+	"time"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+const DEFAULT_HOST_ADDRESS string = ":7777"
+const DEFAULT_JOIN_ADDRESS string = "127.0.0.1:7777"
+const INPUT_DELAY_TICKS int32 = 3
+const CHECKSUM_INTERVAL int32 = 60
+const NET_RESEND_WINDOW int32 = 32
+const NET_TIMEOUT_FRAMES int32 = 300
+const HELLO_INTERVAL_FRAMES int32 = 30
+const NET_PACKET_SIZE int = 8192
+
+type NetMessageKind int
+
+const (
+	HelloMessage NetMessageKind = iota
+	WelcomeMessage
+	InputMessage
+	ByeMessage
+)
+
+// ShipControls as they travel over the wire
+type NetInput struct {
+	Turn      float32 `json:"t,omitempty"`
+	Throttle  float32 `json:"th,omitempty"`
+	Strafe    float32 `json:"s,omitempty"`
+	Abilities uint8   `json:"a,omitempty"` // one bit per ability slot
+}
+
+type NetChecksum struct {
+	Tick int32  `json:"tick"`
+	Sum  uint32 `json:"sum"`
+}
+
+type NetMessage struct {
+	Kind      NetMessageKind `json:"kind"`
+	Ship      int            `json:"ship,omitempty"`      // hello: the joiner's chosen ship
+	Setup     *RunSetup      `json:"setup,omitempty"`     // welcome
+	FirstTick int32          `json:"first,omitempty"`     // input: tick of Inputs[0]
+	Inputs    []NetInput     `json:"inputs,omitempty"`    // input: consecutive ticks from FirstTick
+	Ack       int32          `json:"ack"`                 // input: ticks below this have arrived from the peer
+	Checksums []NetChecksum  `json:"checksums,omitempty"` // input: recent local state checksums
+}
+
+type netPacket struct {
+	message NetMessage
+	from    *net.UDPAddr
+}
+
+// One end of a two-player lockstep session over UDP
+type NetSession struct {
+	game            *Game
+	conn            *net.UDPConn
+	peer            *net.UDPAddr // unknown to the host until someone says hello
+	isHost          bool
+	localPlayer     int
+	started         bool
+	setup           RunSetup // what the host sent, kept to repeat the welcome
+	inbox           chan netPacket
+	inputs          [MAX_PLAYERS]map[int32]ShipControls
+	recorded        int32 // local inputs exist for every tick below this
+	received        int32 // remote inputs exist for every tick below this
+	peerAck         int32 // the peer has our inputs for every tick below this
+	tick            int32 // next tick to simulate
+	checksums       map[int32]uint32
+	remoteChecksums map[int32]uint32
+	silentFrames    int32
+	helloCounter    int32
+}
+
+func openNetSession(g *Game, isHost bool) (*NetSession, error) {
+	n := &NetSession{
+		game:            g,
+		isHost:          isHost,
+		inbox:           make(chan netPacket, 256),
+		checksums:       map[int32]uint32{},
+		remoteChecksums: map[int32]uint32{},
+	}
+
+	listenAddress := ":0"
+	if isHost {
+		listenAddress = g.hostAddress
+	} else {
+		n.localPlayer = 1
+		peer, err := net.ResolveUDPAddr("udp", g.joinAddress)
+		if err != nil {
+			return nil, err
+		}
+		n.peer = peer
+	}
+
+	local, err := net.ResolveUDPAddr("udp", listenAddress)
+	if err != nil {
+		return nil, err
+	}
+	n.conn, err = net.ListenUDP("udp", local)
+	if err != nil {
+		return nil, err
+	}
+	go n.listen()
+	return n, nil
+}
+
+// Read packets on a background goroutine; the game loop drains them each frame
+func (n *NetSession) listen() {
+	buffer := make([]byte, NET_PACKET_SIZE)
+	for {
+		size, from, err := n.conn.ReadFromUDP(buffer)
+		if err != nil {
+			close(n.inbox)
+			return
+		}
+		packet := netPacket{from: from}
+		if err := json.Unmarshal(buffer[:size], &packet.message); err != nil {
+			continue
+		}
+		select {
+		case n.inbox <- packet:
+		default:
+			// Drop it; redundant resends will cover the gap
+		}
+	}
+}
+
+func (n *NetSession) send(m NetMessage) {
+	if n.peer == nil {
+		return
+	}
+	data, err := json.Marshal(m)
+	if err != nil {
+		return
+	}
+	n.conn.WriteToUDP(data, n.peer)
+}
+
+func (n *NetSession) close() {
+	n.conn.Close()
+}
+
+// Start a run from the agreed setup, with the first few ticks of input padded so the delay has something to chew on
+func (n *NetSession) start(setup RunSetup) {
+	g := n.game
+	g.applySetup(setup)
+	g.reloadGameComponents()
+	g.gameState = Play
+
+	for player := range n.inputs {
+		n.inputs[player] = map[int32]ShipControls{}
+		for tick := range INPUT_DELAY_TICKS {
+			n.inputs[player][tick] = ShipControls{}
+		}
+	}
+	n.recorded = INPUT_DELAY_TICKS
+	n.received = INPUT_DELAY_TICKS
+	n.started = true
+}
+
+// The host's view of the run it is about to share
+func (g *Game) netSetup(joinerShip int) RunSetup {
+	mode := g.mode
+	if mode == Solo {
+		mode = Coop
+	}
+	setup := g.runSetup()
+	setup.Seed = g.fixedSeed
+	if setup.Seed == 0 {
+		setup.Seed = time.Now().UnixNano()
+	}
+	setup.Mode = mode
+	setup.Ships = [MAX_PLAYERS]int{g.selectedShips[0], joinerShip}
+	return setup
+}
+
+// Handle everything that arrived since last frame, then tell the peer where we are
+func (n *NetSession) pump() {
+	for drained := false; !drained; {
+		select {
+		case packet, ok := <-n.inbox:
+			if !ok {
+				n.game.leaveNetSession("Connection closed")
+				return
+			}
+			n.silentFrames = 0
+			if !n.handle(packet) {
+				return
+			}
+		default:
+			drained = true
+		}
+	}
+
+	n.silentFrames += 1
+	if n.silentFrames > NET_TIMEOUT_FRAMES && (n.started || !n.isHost) {
+		n.send(NetMessage{Kind: ByeMessage})
+		n.game.leaveNetSession("Connection timed out")
+		return
+	}
+
+	if !n.started {
+		// Keep knocking until the host answers
+		if !n.isHost {
+			n.helloCounter -= 1
+			if n.helloCounter <= 0 {
+				n.send(NetMessage{Kind: HelloMessage, Ship: n.game.selectedShips[0]})
+				n.helloCounter = HELLO_INTERVAL_FRAMES
+			}
+		}
+		return
+	}
+	n.sendInputs()
+}
+
+// Returns false once the session has ended
+func (n *NetSession) handle(packet netPacket) bool {
+	m := packet.message
+	switch m.Kind {
+	case HelloMessage:
+		if !n.isHost {
+			return true
+		}
+		if n.peer == nil {
+			n.peer = packet.from
+		}
+		if packet.from.String() != n.peer.String() {
+			return true
+		}
+		// Repeat the welcome for every hello, in case the last one was lost
+		if !n.started {
+			setup := n.game.netSetup(m.Ship)
+			if err := setup.validate(); err != nil {
+				// Turn the joiner away and wait for someone else
+				n.send(NetMessage{Kind: ByeMessage})
+				n.peer = nil
+				return true
+			}
+			n.setup = setup
+			n.start(n.setup)
+		}
+		n.send(NetMessage{Kind: WelcomeMessage, Setup: &n.setup})
+	case WelcomeMessage:
+		if !n.isHost && !n.started && m.Setup != nil {
+			if err := m.Setup.validate(); err != nil {
+				n.send(NetMessage{Kind: ByeMessage})
+				n.game.leaveNetSession(fmt.Sprintf("The host sent an invalid setup: %v", err))
+				return false
+			}
+			n.start(*m.Setup)
+		}
+	case InputMessage:
+		if !n.started {
+			return true
+		}
+		remote := 1 - n.localPlayer
+		for i, input := range m.Inputs {
+			tick := m.FirstTick + int32(i)
+			if tick >= n.tick {
+				n.inputs[remote][tick] = input.controls()
+			}
+		}
+		for {
+			if _, ok := n.inputs[remote][n.received]; !ok {
+				break
+			}
+			n.received += 1
+		}
+		n.peerAck = max(n.peerAck, m.Ack)
+		for _, checksum := range m.Checksums {
+			n.remoteChecksums[checksum.Tick] = checksum.Sum
+		}
+		return n.checkForDesync()
+	case ByeMessage:
+		n.game.leaveNetSession("The other player left")
+		return false
+	}
+	return true
+}
+
+// Send every local input the peer hasn't confirmed yet, so a lost packet is covered by the next
+func (n *NetSession) sendInputs() {
+	first := max(n.peerAck, n.recorded-NET_RESEND_WINDOW)
+	inputs := []NetInput{}
+	for tick := first; tick < n.recorded; tick++ {
+		inputs = append(inputs, netInput(n.inputs[n.localPlayer][tick]))
+	}
+
+	checksums := []NetChecksum{}
+	for tick, sum := range n.checksums {
+		if tick+CHECKSUM_INTERVAL*4 >= n.tick {
+			checksums = append(checksums, NetChecksum{Tick: tick, Sum: sum})
+		}
+	}
+
+	n.send(NetMessage{Kind: InputMessage, FirstTick: first, Inputs: inputs, Ack: n.received, Checksums: checksums})
+}
+
+// Record this frame's local controls for a tick a little in the future
+func (n *NetSession) recordLocalInput(c ShipControls) {
+	if !n.started || n.recorded > n.tick+INPUT_DELAY_TICKS {
+		return
+	}
+	n.inputs[n.localPlayer][n.recorded] = c
+	n.recorded += 1
+}
+
+// Controls for the next tick, if both players' have arrived
+func (n *NetSession) nextTickControls() ([MAX_PLAYERS]ShipControls, bool) {
+	controls := [MAX_PLAYERS]ShipControls{}
+	if !n.started || n.tick >= n.recorded || n.tick >= n.received {
+		return controls, false
+	}
+	for player := range controls {
+		controls[player] = n.inputs[player][n.tick]
+	}
+	return controls, true
+}
+
+// Checksum the state every so often and forget inputs nobody needs any more
+func (n *NetSession) finishTick() {
+	if n.tick%CHECKSUM_INTERVAL == 0 {
+		n.checksums[n.tick] = n.game.stateChecksum()
+	}
+	delete(n.checksums, n.tick-CHECKSUM_INTERVAL*8)
+
+	// Remote inputs are spent once simulated; local ones are kept until the peer has them
+	delete(n.inputs[1-n.localPlayer], n.tick)
+	for tick := range n.inputs[n.localPlayer] {
+		if tick < n.tick && tick < n.peerAck {
+			delete(n.inputs[n.localPlayer], tick)
+		}
+	}
+	n.tick += 1
+}
+
+// The run is over on both peers at the same tick; resend the tail of our inputs in case the last packet was lost, then hang up
+func (n *NetSession) finish() {
+	for range 3 {
+		n.sendInputs()
+	}
+	n.close()
+}
+
+// Compare checksums for the ticks both peers have simulated; returns false if the session ended
+func (n *NetSession) checkForDesync() bool {
+	for tick, remote := range n.remoteChecksums {
+		local, ok := n.checksums[tick]
+		if !ok {
+			continue
+		}
+		if local != remote {
+			fmt.Fprintf(os.Stderr, "netplay: desync at tick %d: local %08x, remote %08x\n", tick, local, remote)
+			n.send(NetMessage{Kind: ByeMessage})
+			n.game.leaveNetSession(fmt.Sprintf("Desync detected at tick %d", tick))
+			return false
+		}
+		delete(n.remoteChecksums, tick)
+		delete(n.checksums, tick)
+	}
+	return true
+}
+
+func netInput(c ShipControls) NetInput {
+	input := NetInput{Turn: c.turn, Throttle: c.throttle, Strafe: c.strafe}
+	for i, use := range c.abilities {
+		if use {
+			input.Abilities |= 1 << i
+		}
+	}
+	return input
+}
+
+func (input NetInput) controls() ShipControls {
+	c := ShipControls{turn: input.Turn, throttle: input.Throttle, strafe: input.Strafe}
+	for i := range c.abilities {
+		c.abilities[i] = input.Abilities&(1<<i) != 0
+	}
+	return c
+}
+
+// Hash of the state that must match on both peers: ships, black holes, stars and asteroids
+func (g *Game) stateChecksum() uint32 {
+	h := fnv.New32a()
+	write := func(values ...float32) {
+		for _, v := range values {
+			binary.Write(h, binary.LittleEndian, math.Float32bits(v))
+		}
+	}
+	flag := func(b bool) float32 {
+		if b {
+			return 1
+		}
+		return 0
+	}
+
+	for _, ship := range g.ships {
+		write(ship.pos.X, ship.pos.Y, ship.velocity.X, ship.velocity.Y, ship.angle, float32(ship.engineSpeed), ship.fuel, flag(ship.isDead))
+	}
+	for _, blackHole := range g.blackHoleList {
+		write(blackHole.pos.X, blackHole.pos.Y, blackHole.mass, blackHole.radius)
+	}
+	for _, star := range g.starList {
+		write(star.pos.X, star.pos.Y, float32(star.detonationCounter))
+	}
+	for _, asteroid := range g.asteroidList {
+		write(asteroid.pos.X, asteroid.pos.Y, asteroid.velocity.X, asteroid.velocity.Y, flag(asteroid.isAlive))
+	}
+	write(float32(g.score))
+	return h.Sum32()
+}
+
+// Open a session from the menu, waiting in the lobby for the other player
+func (g *Game) enterNetSession(isHost bool) {
+	session, err := openNetSession(g, isHost)
+	if err != nil {
+		g.netStatus = fmt.Sprintf("Could not open a connection: %v", err)
+		return
+	}
+	g.net = session
+	g.netStatus = ""
+	g.gameState = Lobby
+}
+
+// Close the session and return to the start screen, explaining why
+func (g *Game) leaveNetSession(status string) {
+	if g.net == nil {
+		return
+	}
+	g.net.close()
+	g.net = nil
+	g.netStatus = status
+	g.gameState = Start
+	g.mixer.stop(ENGINE_SOUND)
+}
+
+func (g *Game) renderLobby() {
+	text := fmt.Sprintf("Hosting on %s, waiting for another player...", g.hostAddress)
+	if !g.net.isHost {
+		text = fmt.Sprintf("Joining %s...", g.joinAddress)
+	}
+	rl.DrawText(text, int32(g.width)/2-rl.MeasureText(text, 48)/2, 400, 48, rl.RayWhite)
+	hint := "Backspace to cancel"
+	rl.DrawText(hint, int32(g.width)/2-rl.MeasureText(hint, 32)/2, 500, 32, rl.RayWhite)
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"testing"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+func startTestRun(seed int64) *Game {
+	g := initHeadlessGame()
+	g.seed = seed
+	g.bots[0], _ = newBot("random", seed)
+	g.reloadGameComponents()
+	g.gameState = Play
+	return &g
+}
+
+func stepTestRun(g *Game) {
+	g.controls[0] = g.playerControls(0)
+	g.update()
+}
+
+// Two peers fed the same inputs must agree on every tick
+func TestStateChecksumLockstep(t *testing.T) {
+	a, b := startTestRun(3), startTestRun(3)
+	for tick := 0; tick < 600 && a.gameState == Play; tick++ {
+		stepTestRun(a)
+		stepTestRun(b)
+		if sa, sb := a.stateChecksum(), b.stateChecksum(); sa != sb {
+			t.Fatalf("tick %d: checksums %08x and %08x differ", tick, sa, sb)
+		}
+	}
+}
+
+func TestStateChecksumSeeds(t *testing.T) {
+	if startTestRun(1).stateChecksum() == startTestRun(2).stateChecksum() {
+		t.Errorf("runs with different seeds start with the same checksum")
+	}
+}
+
+func TestStateChecksumCoversState(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(g *Game)
+	}{
+		{"ship position", func(g *Game) { g.ships[0].pos.X += 0.5 }},
+		{"ship velocity", func(g *Game) { g.ships[0].velocity.Y += 0.5 }},
+		{"ship heading", func(g *Game) { g.ships[0].angle += 0.1 }},
+		{"ship engine", func(g *Game) { g.ships[0].engineSpeed += 0.1 }},
+		{"ship fuel", func(g *Game) { g.ships[0].fuel -= 1 }},
+		{"ship death", func(g *Game) { g.ships[0].isDead = true }},
+		{"black hole", func(g *Game) { g.addBlackHole(rl.Vector2{X: 400, Y: 300}) }},
+		{"star", func(g *Game) { g.starList[0].detonationCounter -= 1 }},
+		{"asteroid", func(g *Game) {
+			g.asteroidList = append(g.asteroidList, initAsteroid(g, rl.Vector2{X: 100, Y: 100}, StandardAsteroid, rl.Vector2{X: 1}))
+		}},
+		{"score", func(g *Game) { g.score += 1 }},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			g := startTestRun(5)
+			before := g.stateChecksum()
+			test.change(g)
+			if g.stateChecksum() == before {
+				t.Errorf("checksum didn't change")
+			}
+		})
+	}
+}
+
+func TestNetInputRoundTrip(t *testing.T) {
+	tests := []ShipControls{
+		{},
+		{turn: 1, throttle: -1, strafe: 0.5},
+		{abilities: [MAX_ABILITIES]bool{true, false, false, true}},
+		{turn: -0.25, abilities: [MAX_ABILITIES]bool{false, true, true, false}},
+	}
+	for _, controls := range tests {
+		if got := netInput(controls).controls(); got != controls {
+			t.Errorf("%+v came back as %+v", controls, got)
+		}
+	}
+}
@@ -0,0 +1,204 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+const REPLAY_FORMAT int = 1
+
+// Everything needed to start the same run again: shared by network peers and stored in replays
+type RunSetup struct {
+	Seed         int64            `json:"seed"`
+	Mode         GameMode         `json:"mode"`
+	Difficulty   Difficulty       `json:"difficulty"`
+	FlightModel  FlightModel      `json:"flight_model"`
+	FuelMode     bool             `json:"fuel_mode"`
+	CollectStars bool             `json:"collect_stars"`
+	Ships        [MAX_PLAYERS]int `json:"ships"`
+	Width        int              `json:"width"`
+	Height       int              `json:"height"`
+}
+
+func (g *Game) runSetup() RunSetup {
+	return RunSetup{
+		Seed:         g.seed,
+		Mode:         g.mode,
+		Difficulty:   g.difficulty,
+		FlightModel:  g.flightModel,
+		FuelMode:     g.fuelMode,
+		CollectStars: g.collectStars,
+		Ships:        g.selectedShips,
+		Width:        g.width,
+		Height:       g.height,
+	}
+}
+
+// Check a setup from outside the game before anything indexes tables or opens a window with it
+func (s RunSetup) validate() error {
+	if s.Mode < Solo || s.Mode > Versus {
+		return fmt.Errorf("unknown mode %d", s.Mode)
+	}
+	if s.Difficulty < 0 || int(s.Difficulty) >= len(difficultySettings) {
+		return fmt.Errorf("unknown difficulty %d", s.Difficulty)
+	}
+	if s.FlightModel != Arcade && s.FlightModel != Newtonian {
+		return fmt.Errorf("unknown flight model %d", s.FlightModel)
+	}
+	for _, ship := range s.Ships {
+		if ship < 0 || ship >= len(shipRoster) {
+			return fmt.Errorf("unknown ship %d", ship)
+		}
+	}
+	return checkWindowSize(s.Width, s.Height)
+}
+
+// Take on a setup; the field is resized to match so the simulation plays out the same
+func (g *Game) applySetup(s RunSetup) {
+	g.seed = s.Seed
+	g.mode = s.Mode
+	g.difficulty = s.Difficulty
+	g.flightModel = s.FlightModel
+	g.fuelMode = s.FuelMode
+	g.collectStars = s.CollectStars
+	g.selectedShips = s.Ships
+	if s.Width > 0 && s.Height > 0 && (s.Width != g.width || s.Height != g.height) {
+		g.width = s.Width
+		g.height = s.Height
+		if !g.headless {
+			rl.SetWindowSize(g.width, g.height)
+		}
+	}
+}
+
+// A recorded run: its setup, every tick's inputs, and how it ended
+type Replay struct {
+	Format     int          `json:"format"`
+	Version    string       `json:"version"` // of the game that recorded it
+	Setup      RunSetup     `json:"setup"`
+	Inputs     [][]NetInput `json:"inputs"` // one entry per tick, one input per player
+	Ticks      int32        `json:"ticks"`
+	Score      int32        `json:"score"`
+	ShipScores []int32      `json:"ship_scores"`
+	Checksum   uint32       `json:"checksum"` // stateChecksum after the last tick
+	cursor     int
+}
+
+func newReplay(setup RunSetup) *Replay {
+	return &Replay{
+		Format:     REPLAY_FORMAT,
+		Version:    version,
+		Setup:      setup,
+		Inputs:     [][]NetInput{},
+		ShipScores: []int32{},
+	}
+}
+
+func (r *Replay) record(controls [MAX_PLAYERS]ShipControls, players int) {
+	inputs := []NetInput{}
+	for _, c := range controls[:players] {
+		inputs = append(inputs, netInput(c))
+	}
+	r.Inputs = append(r.Inputs, inputs)
+}
+
+// Controls for the next tick of playback, until the recording runs out
+func (r *Replay) next() ([MAX_PLAYERS]ShipControls, bool) {
+	controls := [MAX_PLAYERS]ShipControls{}
+	if r.cursor >= len(r.Inputs) {
+		return controls, false
+	}
+	for i, input := range r.Inputs[r.cursor] {
+		if i < MAX_PLAYERS {
+			controls[i] = input.controls()
+		}
+	}
+	r.cursor += 1
+	return controls, true
+}
+
+// Note how the run ended, for verify to check against
+func (r *Replay) finish(g *Game) {
+	r.Ticks = int32(len(r.Inputs))
+	r.Score = g.score
+	r.ShipScores = []int32{}
+	for _, ship := range g.ships {
+		r.ShipScores = append(r.ShipScores, ship.score)
+	}
+	r.Checksum = g.stateChecksum()
+}
+
+func (r *Replay) save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	data, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+func loadReplay(path string) (*Replay, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	r := &Replay{}
+	if err := json.Unmarshal(data, r); err != nil {
+		return nil, fmt.Errorf("%s is not a replay: %v", path, err)
+	}
+	if r.Format != REPLAY_FORMAT {
+		return nil, fmt.Errorf("%s has replay format %d, but this version reads format %d", path, r.Format, REPLAY_FORMAT)
+	}
+	if err := r.Setup.validate(); err != nil {
+		return nil, fmt.Errorf("%s has an invalid setup: %v", path, err)
+	}
+	return r, nil
+}
+
+func (g *Game) saveRecording() {
+	g.recording.finish(g)
+	if err := g.recording.save(g.recordPath); err != nil {
+		fmt.Fprintf(os.Stderr, "could not save replay: %v\n", err)
+	}
+	g.recording = nil
+}
+
+// Start playing a replay back from its first tick
+func (g *Game) startPlayback(r *Replay) {
+	r.cursor = 0
+	g.applySetup(r.Setup)
+	g.playback = r
+	g.reloadGameComponents()
+	g.gameState = Play
+}
+
+// Re-simulate a replay without a window and check it ends exactly as recorded
+func verifyReplay(r *Replay) error {
+	g := initHeadlessGame()
+	g.startPlayback(r)
+	for g.gameState == Play {
+		g.update()
+	}
+
+	if r.cursor != len(r.Inputs) {
+		return fmt.Errorf("run ended after %d of %d recorded ticks", r.cursor, len(r.Inputs))
+	}
+	if g.score != r.Score {
+		return fmt.Errorf("score %d, but the replay recorded %d", g.score, r.Score)
+	}
+	for i, ship := range g.ships {
+		if i < len(r.ShipScores) && ship.score != r.ShipScores[i] {
+			return fmt.Errorf("player %d scored %d, but the replay recorded %d", i+1, ship.score, r.ShipScores[i])
+		}
+	}
+	if sum := g.stateChecksum(); sum != r.Checksum {
+		return fmt.Errorf("final state checksum %08x, but the replay recorded %08x", sum, r.Checksum)
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func testSetup() RunSetup {
+	return RunSetup{
+		Seed:         42,
+		Mode:         Coop,
+		Difficulty:   Hard,
+		FlightModel:  Newtonian,
+		FuelMode:     true,
+		CollectStars: true,
+		Ships:        [MAX_PLAYERS]int{1, 2},
+		Width:        1280,
+		Height:       720,
+	}
+}
+
+func TestReplayRoundTrip(t *testing.T) {
+	r := newReplay(testSetup())
+	ticks := [][MAX_PLAYERS]ShipControls{
+		{{turn: 1, throttle: 1}, {strafe: -1}},
+		{{}, {turn: -0.5, throttle: -1, abilities: [MAX_ABILITIES]bool{true, false, true, false}}},
+		{{abilities: [MAX_ABILITIES]bool{false, false, false, true}}, {}},
+	}
+	for _, controls := range ticks {
+		r.record(controls, 2)
+	}
+	r.Ticks = int32(len(r.Inputs))
+	r.Score = 1234
+	r.ShipScores = []int32{600, 634}
+	r.Checksum = 0xdeadbeef
+
+	path := filepath.Join(t.TempDir(), "replays", "run.replay")
+	if err := r.save(path); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	loaded, err := loadReplay(path)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if !reflect.DeepEqual(loaded, r) {
+		t.Errorf("loaded %+v, want %+v", loaded, r)
+	}
+
+	// Playback gives back the recorded controls, then runs out
+	for i, want := range ticks {
+		got, ok := loaded.next()
+		if !ok {
+			t.Fatalf("playback ran out at tick %d", i)
+		}
+		if got != want {
+			t.Errorf("tick %d played back %+v, want %+v", i, got, want)
+		}
+	}
+	if _, ok := loaded.next(); ok {
+		t.Errorf("playback went on past the last recorded tick")
+	}
+}
+
+func TestLoadReplayRejects(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(r *Replay)
+	}{
+		{"newer format", func(r *Replay) { r.Format = REPLAY_FORMAT + 1 }},
+		{"unknown mode", func(r *Replay) { r.Setup.Mode = Versus + 1 }},
+		{"unknown difficulty", func(r *Replay) { r.Setup.Difficulty = Difficulty(len(difficultySettings)) }},
+		{"unknown flight model", func(r *Replay) { r.Setup.FlightModel = -1 }},
+		{"unknown ship", func(r *Replay) { r.Setup.Ships[1] = len(shipRoster) }},
+		{"tiny window", func(r *Replay) { r.Setup.Width = 10 }},
+		{"huge window", func(r *Replay) { r.Setup.Height = MAX_WINDOW_HEIGHT + 1 }},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := newReplay(testSetup())
+			test.change(r)
+			path := filepath.Join(t.TempDir(), "run.replay")
+			if err := r.save(path); err != nil {
+				t.Fatalf("save: %v", err)
+			}
+			if _, err := loadReplay(path); err == nil {
+				t.Errorf("loaded without an error")
+			}
+		})
+	}
+
+	path := filepath.Join(t.TempDir(), "garbage.replay")
+	os.WriteFile(path, []byte("not json"), 0o644)
+	if _, err := loadReplay(path); err == nil {
+		t.Errorf("loaded a file that isn't JSON without an error")
+	}
+}
+
+// Record a whole headless run, then check verify plays it out the same
+func TestVerifyRecordedRun(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "run.replay")
+	g := initHeadlessGame()
+	g.bots[0], _ = newBot("random", 7)
+	g.recordPath = path
+	g.runHeadless(7, 0)
+
+	r, err := loadReplay(path)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if r.Ticks == 0 || r.Setup.Seed != 7 {
+		t.Fatalf("recorded %d ticks with seed %d", r.Ticks, r.Setup.Seed)
+	}
+	if err := verifyReplay(r); err != nil {
+		t.Errorf("verify: %v", err)
+	}
+
+	r.Score += 1
+	if err := verifyReplay(r); err == nil {
+		t.Errorf("verify passed a replay with the wrong score")
+	}
+}
@@ -1,7 +1,6 @@
 package main
 
 import (
-	"fmt"
 	"image/color"
 	"math"
 	"math/rand"
@@ -11,38 +10,107 @@ import (
 
 const MAX_SPEED float64 = 5
 const MAX_ENGINE_SPEED float64 = 50
+const FUEL_BURN_RATE float32 = 0.01
+const FUEL_LOW_FRACTION float32 = 0.2
+const STAR_REFUEL_RADIUS float32 = 30
+const STAR_REFUEL_RATE float32 = 0.5
+
+type DeathCause int
+
+const (
+	NotDead DeathCause = iota
+	EdgeDeath
+	AsteroidDeath
+	BlackHoleDeath
+)
+
+func (c DeathCause) name() string {
+	switch c {
+	case EdgeDeath:
+		return "edge"
+	case AsteroidDeath:
+		return "asteroid"
+	case BlackHoleDeath:
+		return "black hole"
+	default:
+		return "survived"
+	}
+}
 
 type Ship struct {
-	game        *Game
-	pos         rl.Vector2 // x, y
-	radius      float32
-	angle       float32
-	velocity    rl.Vector2 // x velocity, y velocity
-	engineSpeed float64
-	vaporTrail  []rl.Vector3 // x position, y position, size
-	isDead      bool
-	texture     rl.Texture2D
-}
-
-func initShip(g *Game, p rl.Vector2, r float32, t rl.Texture2D) Ship {
+	game            *Game
+	player          int
+	pos             rl.Vector2 // x, y
+	radius          float32
+	angle           float32
+	angularVelocity float32
+	velocity        rl.Vector2 // x velocity, y velocity
+	engineSpeed     float64
+	thrustLevel     float64      // Newtonian thrust this tick, from 0 to 1
+	heldControls    ShipControls // steering applied this tick, without the abilities
+	flightModel     FlightModel
+	handling        ShipHandling
+	vaporTrail      []rl.Vector3 // x position, y position, size
+	isDead          bool
+	sprite          *Sprite
+	tint            rl.Color
+	special         ShipSpecial
+	specialCharges  int
+	fuel            float32
+	sputterCounter  int32
+	abilities       []AbilitySlot
+	history         []ShipSample
+	gravityGain     float32 // speed gravity has added over the run, less what it has taken away
+	trajectory      Trajectory
+	score           int32 // own score in versus
+	reviveProgress  int32
+	exploded        bool
+	deathCause      DeathCause
+}
+
+func initShip(g *Game, player int, p rl.Vector2, st ShipType, sprite *Sprite, f FlightModel) Ship {
 	return Ship{
-		game:        g,
-		pos:         p,
-		radius:      r,
-		angle:       0,
-		velocity:    rl.Vector2{X: 0, Y: 0},
-		engineSpeed: 0,
-		vaporTrail:  []rl.Vector3{},
-		isDead:      false,
-		texture:     t,
+		game:            g,
+		player:          player,
+		pos:             p,
+		radius:          st.radius,
+		angle:           0,
+		angularVelocity: 0,
+		velocity:        rl.Vector2{X: 0, Y: 0},
+		engineSpeed:     0,
+		thrustLevel:     0,
+		heldControls:    ShipControls{},
+		flightModel:     f,
+		handling:        st.handling,
+		vaporTrail:      []rl.Vector3{},
+		isDead:          false,
+		sprite:          sprite,
+		tint:            st.tint,
+		special:         st.special,
+		specialCharges:  st.specialCharges,
+		fuel:            st.handling.fuelCapacity,
+		sputterCounter:  0,
+		abilities:       initAbilitySlots(st.abilities),
+		history:         []ShipSample{},
+		gravityGain:     0,
+		trajectory:      Trajectory{},
+		score:           0,
+		reviveProgress:  0,
+		exploded:        false,
+		deathCause:      NotDead,
 	}
 }
 
 func (s *Ship) render() {
-	if !s.isDead {
-		fTextureWidth := float32(s.texture.Width)
-		fTextureHeight := float32(s.texture.Height)
-		rl.DrawTexturePro(s.texture, rl.NewRectangle(0, 0, fTextureWidth, fTextureHeight), rl.NewRectangle(s.pos.X, s.pos.Y, fTextureWidth, fTextureHeight), rl.Vector2{X: fTextureWidth / 2, Y: fTextureHeight / 2}, s.angle*(180/math.Pi)+90, rl.White)
+	if s.isDead {
+		s.renderWreck()
+	} else {
+		fTextureWidth := s.sprite.width()
+		fTextureHeight := s.sprite.height()
+		s.sprite.draw(rl.NewRectangle(s.pos.X, s.pos.Y, fTextureWidth, fTextureHeight), rl.Vector2{X: fTextureWidth / 2, Y: fTextureHeight / 2}, s.angle*(180/math.Pi)+90, s.tint)
+		if s.special == ShieldSpecial && s.specialCharges > 0 {
+			rl.DrawCircleLines(int32(s.pos.X), int32(s.pos.Y), fTextureHeight*0.75, rl.Fade(rl.SkyBlue, 0.7))
+		}
 	}
 	for _, dot := range s.vaporTrail {
 		rl.DrawCircle(int32(dot.X), int32(dot.Y), dot.Z, color.RGBA{255, 95, 31, 100})
@@ -52,32 +120,56 @@ func (s *Ship) render() {
 func (s *Ship) update() {
 	if !s.isDead {
 		// Put a floor on the engine speed
-		s.engineSpeed = math.Min(MAX_ENGINE_SPEED, math.Max(0, float64(s.engineSpeed)))
+		s.limitEngineSpeed()
 
-		// Handle engine sound
-		if s.engineSpeed > 0 && !rl.IsSoundPlaying(s.game.engineSound) {
-			rl.PlaySound(s.game.engineSound)
+		// Tick down ability cooldowns and effects
+		for i := range s.abilities {
+			s.abilities[i].update()
 		}
-		if s.engineSpeed <= 0 && rl.IsSoundPlaying(s.game.engineSound) {
-			rl.StopSound(s.game.engineSound)
+
+		// Turning has momentum in the Newtonian model
+		if s.flightModel == Newtonian {
+			s.rotate()
 		}
-		if rl.IsSoundPlaying(s.game.engineSound) {
-			rl.SetSoundVolume(s.game.engineSound, float32(12.5*s.engineSpeed/MAX_ENGINE_SPEED))
+
+		// Burn fuel and top up from nearby stars
+		if s.game.fuelMode {
+			s.burnFuel()
+			for _, star := range s.game.starList {
+				if rl.Vector2Distance(s.pos, star.pos) < STAR_REFUEL_RADIUS {
+					s.refuel(STAR_REFUEL_RATE)
+				}
+			}
+		}
+
+		if s.isLowOnFuel() {
+			s.sputterCounter += 1
 		}
 
 		// Blow up if we've gone out of bounds
-		if s.pos.Y < 0 || s.pos.Y > float32(WindowHeight) || s.pos.X < 0 || s.pos.X > float32(WindowWidth) {
-			s.isDead = true
-			return
+		if s.pos.Y < 0 || s.pos.Y > float32(s.game.height) || s.pos.X < 0 || s.pos.X > float32(s.game.width) {
+			if s.useSpecial(EdgeBounceSpecial) {
+				s.bounceOffEdge()
+			} else {
+				s.die(EdgeDeath)
+				return
+			}
 		}
 
 		// Check asteroid collisions
-		for _, asteroid := range s.game.asteroidList {
+		for i, asteroid := range s.game.asteroidList {
+			if !asteroid.isAlive {
+				continue
+			}
 			asteroidCollisionCircle := asteroid.getCollisionCircle()
 			if rl.CheckCollisionCircles(s.pos, s.radius, rl.Vector2{X: asteroidCollisionCircle.X, Y: asteroidCollisionCircle.Y}, asteroidCollisionCircle.Z) {
-				s.isDead = true
-				asteroid.isAlive = false
 				s.game.createNewExplosion(asteroid.pos, 15)
+				if s.useSpecial(ShieldSpecial) {
+					s.game.asteroidList[i].isAlive = false
+					continue
+				}
+				s.die(AsteroidDeath)
+				asteroid.isAlive = false
 				return
 			}
 		}
@@ -85,28 +177,14 @@ func (s *Ship) update() {
 		// Check black hole collisions
 		for _, blackHole := range s.game.blackHoleList {
 			if rl.CheckCollisionCircles(s.pos, s.radius, blackHole.pos, blackHole.deathRadius) {
-				fmt.Println("collided with black hole")
-				fmt.Printf("s.pos: %v\ns.radius: %v\nb.pos: %v\nb.radius: %v\n", s.pos, s.radius, blackHole.pos, blackHole.deathRadius)
-				fmt.Println(blackHole)
-				s.isDead = true
+				s.die(BlackHoleDeath)
 				return
 			}
-
-			// Calculate velocity updates from black hole
-			s.velocity = rl.Vector2Add(s.velocity, blackHole.calculateForceOnObject(s.pos))
 		}
 
-		// Calculate new position
-		s.pos = rl.Vector2Add(s.pos, rl.Vector2{
-			X: float32(math.Cos(float64(s.angle))*s.engineSpeed) + s.velocity.X,
-			Y: float32(math.Sin(float64(s.angle))*s.engineSpeed) + s.velocity.Y,
-		})
-
-		// Cap velocities so we don't get too crazy
-		s.velocity = rl.Vector2{
-			X: float32(math.Min(MAX_SPEED, math.Max(-MAX_SPEED, float64(s.velocity.X)))),
-			Y: float32(math.Min(MAX_SPEED, math.Max(-MAX_SPEED, float64(s.velocity.Y)))),
-		}
+		// Fly through the gravitational field
+		s.move()
+		s.recordHistory()
 
 		// Update the vapor trail
 		newVaporTrail := []rl.Vector3{}
@@ -121,9 +199,9 @@ func (s *Ship) update() {
 		vaporFudgeFactor := float64(rand.Float32()-0.5) * 8.0
 		theta := float64((math.Pi / 2) - s.angle)
 		vaporDot := rl.Vector3{
-			X: float32(float64(s.pos.X) - (float64(s.texture.Height)+vaporFudgeFactor)*math.Sin(theta)/2),
-			Y: float32(float64(s.pos.Y) - (float64(s.texture.Height)+vaporFudgeFactor)*math.Cos(theta)/2),
-			Z: float32(s.engineSpeed) / 2,
+			X: float32(float64(s.pos.X) - (float64(s.sprite.height())+vaporFudgeFactor)*math.Sin(theta)/2),
+			Y: float32(float64(s.pos.Y) - (float64(s.sprite.height())+vaporFudgeFactor)*math.Cos(theta)/2),
+			Z: float32(s.enginePower()*s.handling.maxEngineSpeed) / 2,
 		}
 		newVaporTrail = append(newVaporTrail, vaporDot)
 
@@ -133,10 +211,124 @@ func (s *Ship) update() {
 	}
 }
 
+// Advance the position by one tick under engine power and gravity
+func (s *Ship) move() {
+	// Engine and boost hold still through the move, so any change in speed is down to gravity and the cap
+	speed := rl.Vector2Length(s.currentVelocity())
+
+	// Calculate velocity updates from the gravitational field
+	s.velocity = rl.Vector2Add(s.velocity, rl.Vector2Scale(s.game.calculateFieldForce(s.pos), s.handling.gravityScale))
+
+	// Pass through any wormhole we've flown into
+	for _, wormhole := range s.game.wormholeList {
+		if exit, ok := wormhole.teleport(s.pos, s.currentVelocity()); ok {
+			s.pos = exit
+			break
+		}
+	}
+
+	// Calculate new position
+	s.pos = rl.Vector2Add(s.pos, s.currentVelocity())
+
+	// Cap velocities so we don't get too crazy
+	if s.flightModel == Newtonian {
+		s.velocity = rl.Vector2ClampValue(s.velocity, 0, s.handling.maxNewtonianSpeed)
+	} else {
+		s.velocity = rl.Vector2{
+			X: float32(math.Min(s.handling.maxSpeed, math.Max(-s.handling.maxSpeed, float64(s.velocity.X)))),
+			Y: float32(math.Min(s.handling.maxSpeed, math.Max(-s.handling.maxSpeed, float64(s.velocity.Y)))),
+		}
+	}
+
+	// Only count speed the ship gets to keep, so one already at the cap can't bank phantom gains
+	s.gravityGain += rl.Vector2Length(s.currentVelocity()) - speed
+}
+
+// Combined engine and gravity velocity, as applied to the position each tick
+func (s *Ship) currentVelocity() rl.Vector2 {
+	if s.flightModel == Newtonian {
+		return rl.Vector2Add(s.velocity, s.boostVelocity())
+	}
+	return rl.Vector2Add(rl.Vector2{
+		X: float32(math.Cos(float64(s.angle))*s.engineSpeed) + s.velocity.X,
+		Y: float32(math.Sin(float64(s.angle))*s.engineSpeed) + s.velocity.Y,
+	}, s.boostVelocity())
+}
+
+func (s *Ship) die(cause DeathCause) {
+	s.isDead = true
+	s.deathCause = cause
+}
+
+// Bring a wreck back to life where it lies
+func (s *Ship) revive() {
+	s.isDead = false
+	s.deathCause = NotDead
+	s.exploded = false
+	s.reviveProgress = 0
+	s.velocity = rl.Vector2{}
+	s.angularVelocity = 0
+	s.engineSpeed = 0
+	s.fuel = max(s.fuel, s.handling.fuelCapacity/2)
+}
+
+// Use fuel in proportion to engine power; an empty tank shuts the engine down
+func (s *Ship) burnFuel() {
+	s.fuel -= float32(s.enginePower()*s.handling.maxEngineSpeed) * FUEL_BURN_RATE
+	if s.fuel <= 0 {
+		s.fuel = 0
+		s.engineSpeed = 0
+		s.thrustLevel = 0
+	}
+}
+
+func (s *Ship) refuel(amount float32) {
+	s.fuel = min(s.handling.fuelCapacity, s.fuel+amount)
+}
+
+func (s *Ship) isLowOnFuel() bool {
+	return s.game.fuelMode && s.fuel < s.handling.fuelCapacity*FUEL_LOW_FRACTION
+}
+
+// Fuel gauge for the HUD
+func (s *Ship) renderFuelGauge(x int32, y int32) {
+	width := int32(200)
+	fraction := s.fuel / s.handling.fuelCapacity
+	color := rl.Lime
+	if s.isLowOnFuel() {
+		color = rl.Red
+	}
+	rl.DrawRectangleLines(x, y, width, 16, rl.RayWhite)
+	rl.DrawRectangle(x+2, y+2, int32(float32(width-4)*fraction), 12, color)
+	rl.DrawText("Fuel", x+width+10, y-2, 20, rl.RayWhite)
+}
+
+// Spend a charge of the given special, if this ship has it and any are left
+func (s *Ship) useSpecial(special ShipSpecial) bool {
+	if s.special != special || s.specialCharges <= 0 {
+		return false
+	}
+	s.specialCharges -= 1
+	return true
+}
+
+// Reflect off whichever screen edges we've crossed
+func (s *Ship) bounceOffEdge() {
+	if s.pos.X < 0 || s.pos.X > float32(s.game.width) {
+		s.velocity.X = -s.velocity.X
+		s.angle = math.Pi - s.angle
+	}
+	if s.pos.Y < 0 || s.pos.Y > float32(s.game.height) {
+		s.velocity.Y = -s.velocity.Y
+		s.angle = -s.angle
+	}
+	s.pos = rl.Vector2Clamp(s.pos, rl.Vector2{}, rl.Vector2{X: float32(s.game.width), Y: float32(s.game.height)})
+}
+
 func (s *Ship) increaseSpeed() {
-	s.engineSpeed += 0.1
+	s.engineSpeed += s.handling.throttleStep
 }
 
 func (s *Ship) decreaseSpeed() {
-	s.engineSpeed -= 0.1
+	s.engineSpeed -= s.handling.throttleStep
 }
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"math"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+type ShipSpecial int
+
+const (
+	NoSpecial ShipSpecial = iota
+	// Absorbs asteroid hits while charges remain
+	ShieldSpecial
+	// Bounces off the screen edges while charges remain
+	EdgeBounceSpecial
+	// Doubles the length of the predicted path
+	LongSightSpecial
+)
+
+func (s ShipSpecial) description() string {
+	switch s {
+	case ShieldSpecial:
+		return "Shield: survives one asteroid hit"
+	case EdgeBounceSpecial:
+		return "Bouncer: rebounds off the screen edge three times"
+	case LongSightSpecial:
+		return "Long sight: sees twice as far ahead"
+	default:
+		return "No special"
+	}
+}
+
+// Data definition of a selectable ship
+type ShipType struct {
+	name           string
+	sprite         string // texture name in the asset manifest
+	frame          string // frame in the texture's atlas; empty draws the whole texture
+	tint           rl.Color
+	radius         float32
+	handling       ShipHandling
+	special        ShipSpecial
+	specialCharges int
+	abilities      []AbilityKind
+}
+
+var shipRoster = []ShipType{
+	{
+		name:      "Wayfarer",
+		sprite:    "images/ship.png",
+		tint:      rl.White,
+		radius:    5,
+		handling:  standardHandling,
+		special:   LongSightSpecial,
+		abilities: []AbilityKind{BoostAbility, TimeSlowAbility, GravityBombAbility},
+	},
+	{
+		name:   "Sparrow",
+		sprite: "images/ship.png",
+		tint:   rl.SkyBlue,
+		radius: 4,
+		handling: ShipHandling{
+			maxEngineSpeed:      40,
+			throttleStep:        0.15,
+			maxSpeed:            6,
+			turnRate:            math.Pi / 45,
+			gravityScale:        1.25,
+			fuelCapacity:        80,
+			thrust:              0.16,
+			retroThrust:         0.12,
+			strafeThrust:        0.1,
+			maxNewtonianSpeed:   12,
+			angularAcceleration: 0.009,
+			angularDamping:      0.88,
+			maxAngularVelocity:  math.Pi / 22,
+		},
+		special:        EdgeBounceSpecial,
+		specialCharges: 3,
+		abilities:      []AbilityKind{BoostAbility, TimeSlowAbility},
+	},
+	{
+		name:   "Bulwark",
+		sprite: "images/ship.png",
+		tint:   rl.Orange,
+		radius: 7,
+		handling: ShipHandling{
+			maxEngineSpeed:      55,
+			throttleStep:        0.07,
+			maxSpeed:            4,
+			turnRate:            math.Pi / 90,
+			gravityScale:        0.7,
+			fuelCapacity:        140,
+			thrust:              0.08,
+			retroThrust:         0.06,
+			strafeThrust:        0.04,
+			maxNewtonianSpeed:   8,
+			angularAcceleration: 0.004,
+			angularDamping:      0.92,
+			maxAngularVelocity:  math.Pi / 45,
+		},
+		special:        ShieldSpecial,
+		specialCharges: 1,
+		abilities:      []AbilityKind{TimeSlowAbility, GravityBombAbility},
+	},
+}
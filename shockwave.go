@@ -0,0 +1,106 @@
+package main
+
+import (
+	"math"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+const SHOCKWAVE_SPEED float32 = 8.0
+const SHOCKWAVE_MAX_RADIUS float32 = 320.0
+const SHOCKWAVE_WIDTH float32 = 24.0
+const SHOCKWAVE_STRENGTH float32 = 1.2
+const SHOCKWAVE_DAMAGE_RADIUS float32 = 120.0
+const HAWKING_BURST_SPEED float32 = 6.0
+const HAWKING_BURST_MAX_RADIUS float32 = 160.0
+const HAWKING_BURST_STRENGTH float32 = 0.8
+
+// Expanding ring that shoves anything caught in its front
+type Shockwave struct {
+	game         *Game
+	pos          rl.Vector2
+	radius       float32
+	maxRadius    float32
+	speed        float32
+	strength     float32
+	damageRadius float32 // asteroids hit inside this radius are destroyed rather than pushed
+	color        rl.Color
+}
+
+// Shockwave from a supernova
+func initShockwave(g *Game, p rl.Vector2) Shockwave {
+	return Shockwave{
+		game:         g,
+		pos:          p,
+		radius:       0,
+		maxRadius:    SHOCKWAVE_MAX_RADIUS,
+		speed:        SHOCKWAVE_SPEED,
+		strength:     SHOCKWAVE_STRENGTH,
+		damageRadius: SHOCKWAVE_DAMAGE_RADIUS,
+		color:        rl.Orange,
+	}
+}
+
+// Short repulsive kick from a black hole's final evaporation
+func initHawkingBurst(g *Game, p rl.Vector2) Shockwave {
+	return Shockwave{
+		game:         g,
+		pos:          p,
+		radius:       0,
+		maxRadius:    HAWKING_BURST_MAX_RADIUS,
+		speed:        HAWKING_BURST_SPEED,
+		strength:     HAWKING_BURST_STRENGTH,
+		damageRadius: 0,
+		color:        rl.Violet,
+	}
+}
+
+func (s *Shockwave) render() {
+	alpha := 1 - s.radius/s.maxRadius
+	rl.DrawRing(s.pos, max(0, s.radius-SHOCKWAVE_WIDTH), s.radius, 0, 360, 64, rl.Fade(s.color, 0.5*alpha))
+	rl.DrawCircleLines(int32(s.pos.X), int32(s.pos.Y), s.radius, rl.Fade(rl.ColorBrightness(s.color, 0.4), alpha))
+}
+
+func (s *Shockwave) update() {
+	s.radius += s.speed
+
+	// Push the ships
+	for i := range s.game.ships {
+		ship := &s.game.ships[i]
+		if !ship.isDead {
+			ship.velocity = rl.Vector2Add(ship.velocity, s.pushOnObject(ship.pos))
+		}
+	}
+
+	// Push or smash the asteroids
+	for i := range s.game.asteroidList {
+		asteroid := &s.game.asteroidList[i]
+		if !asteroid.isAlive || !s.inFront(asteroid.pos) {
+			continue
+		}
+		if s.radius < s.damageRadius {
+			asteroid.destroy()
+		} else {
+			asteroid.velocity = rl.Vector2Add(asteroid.velocity, s.pushOnObject(asteroid.pos))
+		}
+	}
+}
+
+func (s *Shockwave) inFront(obj rl.Vector2) bool {
+	dis := rl.Vector2Distance(s.pos, obj)
+	return dis <= s.radius && dis > s.radius-SHOCKWAVE_WIDTH
+}
+
+// Outward push on an object, weakening as the ring spreads
+func (s *Shockwave) pushOnObject(obj rl.Vector2) rl.Vector2 {
+	if !s.inFront(obj) {
+		return rl.Vector2{}
+	}
+	angle := math.Atan2(float64(obj.Y-s.pos.Y), float64(obj.X-s.pos.X))
+	strength := float64(s.strength * (1 - s.radius/s.maxRadius))
+	return rl.Vector2{X: float32(math.Cos(angle) * strength), Y: float32(math.Sin(angle) * strength)}
+}
+
+func (s *Shockwave) isDone() bool {
+	return s.radius >= s.maxRadius
+}
@@ -0,0 +1,229 @@
+package main
+
+import (
+	"math"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+const SPAWN_ATTEMPTS int = 30
+const SPAWN_TELEGRAPH_TICKS int32 = 60
+const ASTEROID_TELEGRAPH_TICKS int32 = 45
+const ASTEROID_MIN_REACTION_TICKS float32 = 60
+const ASTEROID_SAFE_PASS_DISTANCE float32 = 60
+
+type SpawnKind int
+
+const (
+	StarSpawn SpawnKind = iota
+	AsteroidSpawn
+)
+
+// Placement constraints for a spawned object
+type SpawnRules struct {
+	minShipDistance      float32
+	minBlackHoleDistance float32
+	minStarDistance      float32
+	edgeMargin           float32
+	attempts             int
+}
+
+var starSpawnRules = SpawnRules{
+	minShipDistance:      220,
+	minBlackHoleDistance: 160,
+	minStarDistance:      90,
+	edgeMargin:           60,
+	attempts:             SPAWN_ATTEMPTS,
+}
+
+// A pending spawn, shown as a warning marker before the object appears
+type SpawnTelegraph struct {
+	game     *Game
+	kind     SpawnKind
+	origin   rl.Vector2 // where a spawning star flies in from
+	pos      rl.Vector2
+	velocity rl.Vector2
+	class    AsteroidClassKind
+	counter  int32
+	duration int32
+}
+
+func initSpawnTelegraph(g *Game, k SpawnKind, o rl.Vector2, p rl.Vector2, v rl.Vector2, c AsteroidClassKind, d int32) SpawnTelegraph {
+	return SpawnTelegraph{
+		game:     g,
+		kind:     k,
+		origin:   o,
+		pos:      p,
+		velocity: v,
+		class:    c,
+		counter:  d,
+		duration: d,
+	}
+}
+
+func (t *SpawnTelegraph) update() {
+	t.counter -= 1
+}
+
+func (t *SpawnTelegraph) render() {
+	progress := 1 - float32(t.counter)/float32(t.duration)
+	pulse := float32(math.Abs(math.Sin(float64(t.counter) * math.Pi / 15)))
+
+	switch t.kind {
+	case StarSpawn:
+		// Shrinking ring closing in on the spawn point
+		rl.DrawCircleLines(int32(t.pos.X), int32(t.pos.Y), 40*(1-progress)+8, rl.Fade(rl.Yellow, 0.4+0.6*pulse))
+
+		// A mote of matter flies in from the origin and ignites into the star
+		mote := rl.Vector2Lerp(t.origin, t.pos, min(1, 2*progress))
+		rl.DrawCircleV(mote, 3+3*progress, rl.Fade(rl.Gold, 0.8))
+		if progress > 0.5 {
+			sprite := t.game.starSprite
+			scale := STAR_RENDER_SCALE * (progress - 0.5) * 2
+			sprite.draw(
+				rl.NewRectangle(t.pos.X, t.pos.Y, sprite.width()*scale, sprite.height()*scale),
+				rl.Vector2{X: sprite.width() * scale / 2, Y: sprite.height() * scale / 2},
+				360*progress,
+				rl.Fade(rl.Yellow, progress),
+			)
+		}
+	case AsteroidSpawn:
+		// Arrow on the screen edge where the asteroid will enter
+		t.game.drawThreatArrow(t.pos, t.velocity)
+	}
+}
+
+// Returns how much room a point has to spare against the rules; negative means the point breaks a rule
+func (g *Game) spawnClearance(p rl.Vector2, rules SpawnRules) float32 {
+	clearance := float32(math.Min(
+		math.Min(float64(p.X), float64(float32(g.width)-p.X)),
+		math.Min(float64(p.Y), float64(float32(g.height)-p.Y)),
+	)) - rules.edgeMargin
+
+	for _, ship := range g.ships {
+		clearance = min(clearance, rl.Vector2Distance(p, ship.pos)-rules.minShipDistance)
+	}
+
+	for _, blackHole := range g.blackHoleList {
+		clearance = min(clearance, rl.Vector2Distance(p, blackHole.pos)-rules.minBlackHoleDistance)
+	}
+
+	for _, whiteHole := range g.whiteHoleList {
+		clearance = min(clearance, rl.Vector2Distance(p, whiteHole.pos)-rules.minBlackHoleDistance)
+	}
+
+	for _, wormhole := range g.wormholeList {
+		for _, mouth := range wormhole.mouths {
+			clearance = min(clearance, rl.Vector2Distance(p, mouth)-rules.minBlackHoleDistance)
+		}
+	}
+
+	for _, star := range g.starList {
+		clearance = min(clearance, rl.Vector2Distance(p, star.pos)-rules.minStarDistance)
+	}
+
+	// Pending stars count as stars
+	for _, telegraph := range g.spawnTelegraphList {
+		if telegraph.kind == StarSpawn {
+			clearance = min(clearance, rl.Vector2Distance(p, telegraph.pos)-rules.minStarDistance)
+		}
+	}
+
+	return clearance
+}
+
+// Pick a random point satisfying the rules, falling back to the roomiest candidate if the budget runs out
+func (g *Game) findSpawnPoint(rules SpawnRules) rl.Vector2 {
+	margin := int(rules.edgeMargin)
+	best := rl.Vector2{X: float32(g.width) / 2, Y: float32(g.height) / 2}
+	bestClearance := float32(math.Inf(-1))
+
+	for range max(1, rules.attempts) {
+		p := rl.Vector2{
+			X: float32(g.rng.Intn(g.width-2*margin) + margin),
+			Y: float32(g.rng.Intn(g.height-2*margin) + margin),
+		}
+		clearance := g.spawnClearance(p, rules)
+		if clearance >= 0 {
+			return p
+		}
+		if clearance > bestClearance {
+			best = p
+			bestClearance = clearance
+		}
+	}
+
+	return best
+}
+
+// Ticks until an asteroid on a straight path comes within the safe pass distance of any living ship, or +Inf if it never does
+func (g *Game) asteroidTimeToShip(p rl.Vector2, v rl.Vector2) float32 {
+	soonest := float32(math.Inf(1))
+	for _, ship := range g.ships {
+		if !ship.isDead {
+			soonest = min(soonest, asteroidTimeToPoint(p, v, ship.pos))
+		}
+	}
+	return soonest
+}
+
+// Ticks until an asteroid on a straight path comes within the safe pass distance of a point, or +Inf if it never does
+func asteroidTimeToPoint(p rl.Vector2, v rl.Vector2, target rl.Vector2) float32 {
+	offset := rl.Vector2Subtract(target, p)
+	if rl.Vector2Length(offset) < ASTEROID_SAFE_PASS_DISTANCE {
+		// Already too close, whichever way it's heading
+		return 0
+	}
+	speedSq := rl.Vector2DotProduct(v, v)
+	if speedSq == 0 {
+		return float32(math.Inf(1))
+	}
+
+	closestTime := rl.Vector2DotProduct(offset, v) / speedSq
+	if closestTime < 0 {
+		return float32(math.Inf(1))
+	}
+	closestDistance := rl.Vector2Distance(target, rl.Vector2Add(p, rl.Vector2Scale(v, closestTime)))
+	if closestDistance >= ASTEROID_SAFE_PASS_DISTANCE {
+		return float32(math.Inf(1))
+	}
+
+	// Back up from the closest point to where the path first enters the safe pass distance
+	backoff := float32(math.Sqrt(float64(ASTEROID_SAFE_PASS_DISTANCE*ASTEROID_SAFE_PASS_DISTANCE-closestDistance*closestDistance))) / float32(math.Sqrt(float64(speedSq)))
+	return max(0, closestTime-backoff)
+}
+
+func (g *Game) isSafeAsteroidLaunch(p rl.Vector2, v rl.Vector2) bool {
+	return g.asteroidTimeToShip(p, v) >= ASTEROID_MIN_REACTION_TICKS
+}
+
+func (g *Game) isSafeAsteroidPattern(launches []AsteroidLaunch) bool {
+	for _, launch := range launches {
+		if !g.isSafeAsteroidLaunch(launch.pos, launch.velocity) {
+			return false
+		}
+	}
+	return true
+}
+
+func (g *Game) queueStarSpawn(origin rl.Vector2) {
+	p := g.findSpawnPoint(starSpawnRules)
+	g.spawnTelegraphList = append(g.spawnTelegraphList, initSpawnTelegraph(g, StarSpawn, origin, p, rl.Vector2{}, StandardAsteroid, SPAWN_TELEGRAPH_TICKS))
+}
+
+func (g *Game) queueAsteroidSpawn(l AsteroidLaunch) {
+	g.spawnTelegraphList = append(g.spawnTelegraphList, initSpawnTelegraph(g, AsteroidSpawn, l.pos, l.pos, l.velocity, l.class, ASTEROID_TELEGRAPH_TICKS+l.delay))
+}
+
+// Spawn the object behind a finished telegraph
+func (g *Game) completeSpawn(t SpawnTelegraph) {
+	switch t.kind {
+	case StarSpawn:
+		g.starList = append(g.starList, initStar(g, t.pos, 5))
+	case AsteroidSpawn:
+		// The ship has moved since the launch was queued, so check again and call it off if it's now unfair
+		if g.isSafeAsteroidLaunch(t.pos, t.velocity) {
+			g.asteroidList = append(g.asteroidList, initAsteroid(g, t.pos, t.class, t.velocity))
+		}
+	}
+}
@@ -2,12 +2,16 @@ package main
 
 import (
 	"math"
-	"math/rand"
 
 	rl "github.com/gen2brain/raylib-go/raylib"
 )
 
 const STAR_RENDER_SCALE float32 = 1.5
+const STAR_FLASH_TICKS int32 = 45
+const STAR_RING_RADIUS float32 = 18
+const STAR_COLLECT_RADIUS float32 = 14
+const STAR_BASE_SCORE float32 = 200
+const STAR_RISK_BONUS float32 = 4
 
 type Star struct {
 	game              *Game
@@ -20,13 +24,13 @@ type Star struct {
 }
 
 func initStar(g *Game, p rl.Vector2, r float32) Star {
-	detonationVal := int32(rand.Intn(300)) + 300
+	detonationVal := int32(g.rng.Intn(300)) + 300
 	return Star{
 		game:              g,
 		pos:               p,
 		radius:            r,
-		angle:             rand.Float32() * 2 * math.Pi,
-		turningDirection:  rand.Intn(2) > 0,
+		angle:             g.rng.Float32() * 2 * math.Pi,
+		turningDirection:  g.rng.Intn(2) > 0,
 		timeToDetonation:  detonationVal,
 		detonationCounter: detonationVal,
 	}
@@ -42,6 +46,12 @@ func (s *Star) update() {
 	s.detonationCounter -= 1
 }
 
+// Points for collecting the star, growing the closer it was to detonating
+func (s *Star) collectionScore() int32 {
+	risk := 1 - float32(s.detonationCounter)/float32(s.timeToDetonation)
+	return int32(STAR_BASE_SCORE * (1 + STAR_RISK_BONUS*risk*risk))
+}
+
 func (s *Star) render() {
 	var color rl.Color
 	if float32(s.detonationCounter) < (float32(s.timeToDetonation) * float32(0.33)) {
@@ -52,17 +62,26 @@ func (s *Star) render() {
 		color = rl.Yellow
 	}
 
-	t := s.game.starTexture
+	sprite := s.game.starSprite
 
-	rl.DrawTexturePro(
-		s.game.starTexture,
-		rl.NewRectangle(0, 0, float32(t.Width), float32(t.Height)),
-		rl.NewRectangle(s.pos.X, s.pos.Y, float32(t.Width)*STAR_RENDER_SCALE, float32(t.Height)*STAR_RENDER_SCALE),
+	sprite.draw(
+		rl.NewRectangle(s.pos.X, s.pos.Y, sprite.width()*STAR_RENDER_SCALE, sprite.height()*STAR_RENDER_SCALE),
 		rl.Vector2{
-			X: (float32(t.Width) / 2) * STAR_RENDER_SCALE,
-			Y: (float32(t.Height) / 2) * STAR_RENDER_SCALE,
+			X: (sprite.width() / 2) * STAR_RENDER_SCALE,
+			Y: (sprite.height() / 2) * STAR_RENDER_SCALE,
 		},
 		s.angle*(180/math.Pi),
 		color,
 	)
+
+	// Countdown ring, emptying as detonation approaches
+	remaining := float32(s.detonationCounter) / float32(s.timeToDetonation)
+	rl.DrawRing(s.pos, STAR_RING_RADIUS, STAR_RING_RADIUS+3, -90, -90+360*remaining, 48, rl.Fade(color, 0.7))
+
+	// Flash just before going supernova
+	if s.detonationCounter < STAR_FLASH_TICKS {
+		progress := 1 - float32(s.detonationCounter)/float32(STAR_FLASH_TICKS)
+		pulse := float32(math.Abs(math.Sin(float64(s.detonationCounter) * math.Pi / 6)))
+		rl.DrawCircleV(s.pos, sprite.width()*STAR_RENDER_SCALE*(0.5+progress), rl.Fade(rl.White, 0.2+0.6*pulse*progress))
+	}
 }
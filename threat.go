@@ -0,0 +1,66 @@
+package main
+
+import (
+	"math"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+const THREAT_HORIZON_TICKS float32 = 180
+const THREAT_ARROW_SIZE float32 = 14
+const THREAT_EDGE_INSET float32 = 18
+
+// How pressing an incoming asteroid is, from 0 (won't hit the ship) to 1 (about to)
+func (g *Game) threatUrgency(p rl.Vector2, v rl.Vector2) float32 {
+	ticks := g.asteroidTimeToShip(p, v)
+	if math.IsInf(float64(ticks), 1) {
+		return 0
+	}
+	return float32(math.Max(0, math.Min(1, float64(1-ticks/THREAT_HORIZON_TICKS))))
+}
+
+func (g *Game) isOnScreen(p rl.Vector2) bool {
+	return p.X >= 0 && p.X <= float32(g.width) && p.Y >= 0 && p.Y <= float32(g.height)
+}
+
+// Whether an off-screen point is moving back toward the screen on every axis it's outside of
+func (g *Game) isHeadingOnScreen(p rl.Vector2, v rl.Vector2) bool {
+	if (p.X < 0 && v.X <= 0) || (p.X > float32(g.width) && v.X >= 0) {
+		return false
+	}
+	if (p.Y < 0 && v.Y <= 0) || (p.Y > float32(g.height) && v.Y >= 0) {
+		return false
+	}
+	return true
+}
+
+// Arrow on the screen edge pointing along the asteroid's heading, hotter and faster-blinking as impact nears
+func (g *Game) drawThreatArrow(p rl.Vector2, v rl.Vector2) {
+	urgency := g.threatUrgency(p, v)
+
+	tip := rl.Vector2{
+		X: float32(math.Min(float64(float32(g.width)-THREAT_EDGE_INSET), math.Max(float64(THREAT_EDGE_INSET), float64(p.X)))),
+		Y: float32(math.Min(float64(float32(g.height)-THREAT_EDGE_INSET), math.Max(float64(THREAT_EDGE_INSET), float64(p.Y)))),
+	}
+	heading := math.Atan2(float64(v.Y), float64(v.X))
+	size := THREAT_ARROW_SIZE * (1 + urgency)
+	back := rl.Vector2Subtract(tip, headingVelocity(heading, size))
+	left := rl.Vector2Add(back, headingVelocity(heading+math.Pi/2, size/2))
+	right := rl.Vector2Add(back, headingVelocity(heading-math.Pi/2, size/2))
+
+	blinkRate := 4 + 12*float64(urgency)
+	blink := float32(math.Abs(math.Sin(rl.GetTime() * blinkRate)))
+	color := rl.ColorLerp(rl.Yellow, rl.Red, urgency)
+	// Draw both windings so the arrow shows whichever way it faces
+	rl.DrawTriangle(tip, left, right, rl.Fade(color, 0.4+0.6*blink))
+	rl.DrawTriangle(tip, right, left, rl.Fade(color, 0.4+0.6*blink))
+}
+
+// Warn about every asteroid that is still off screen and on its way in
+func (g *Game) renderThreatIndicators() {
+	for _, asteroid := range g.asteroidList {
+		if !g.isOnScreen(asteroid.pos) && g.isHeadingOnScreen(asteroid.pos, asteroid.velocity) {
+			g.drawThreatArrow(asteroid.pos, asteroid.velocity)
+		}
+	}
+}
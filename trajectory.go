@@ -0,0 +1,73 @@
+package main
+
+import (
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+const TRAJECTORY_DOT_SPACING int = 3
+
+// Predicted path of the ship, assuming the current controls stay held
+type Trajectory struct {
+	points    []rl.Vector2
+	hitsDeath bool // the path ends in a black hole, an asteroid or off screen
+}
+
+// Forward-simulate a copy of a ship through the current field, steering as the player is now.
+// Black holes are treated as frozen and asteroids as drifting at their current velocity.
+func (g *Game) predictTrajectory(s *Ship, ticks int) Trajectory {
+	trajectory := Trajectory{points: []rl.Vector2{}}
+	ghost := *s
+	shielded := s.special == ShieldSpecial && s.specialCharges > 0
+
+	for tick := range ticks {
+		// Same order as a real tick: controls, then the ship's own update
+		ghost.steer(ghost.heldControls)
+		ghost.limitEngineSpeed()
+		if ghost.flightModel == Newtonian {
+			ghost.rotate()
+		}
+		ghost.move()
+		trajectory.points = append(trajectory.points, ghost.pos)
+
+		if ghost.pos.Y < 0 || ghost.pos.Y > float32(g.height) || ghost.pos.X < 0 || ghost.pos.X > float32(g.width) {
+			trajectory.hitsDeath = true
+			return trajectory
+		}
+		for _, blackHole := range g.blackHoleList {
+			if rl.CheckCollisionCircles(ghost.pos, ghost.radius, blackHole.pos, blackHole.deathRadius) {
+				trajectory.hitsDeath = true
+				return trajectory
+			}
+		}
+		if shielded {
+			continue
+		}
+		for _, asteroid := range g.asteroidList {
+			if !asteroid.isAlive {
+				continue
+			}
+			circle := asteroid.getCollisionCircle()
+			center := rl.Vector2Add(rl.Vector2{X: circle.X, Y: circle.Y}, rl.Vector2Scale(asteroid.velocity, float32(tick+1)))
+			if rl.CheckCollisionCircles(ghost.pos, ghost.radius, center, circle.Z) {
+				trajectory.hitsDeath = true
+				return trajectory
+			}
+		}
+	}
+
+	return trajectory
+}
+
+func (t *Trajectory) render() {
+	for i := 0; i < len(t.points); i += TRAJECTORY_DOT_SPACING {
+		alpha := 0.8 * (1 - float32(i)/float32(len(t.points)))
+		rl.DrawCircleV(t.points[i], 1.5, rl.Fade(rl.SkyBlue, alpha))
+	}
+
+	// Mark where the path would kill us
+	if t.hitsDeath && len(t.points) > 0 {
+		end := t.points[len(t.points)-1]
+		rl.DrawLineEx(rl.Vector2{X: end.X - 6, Y: end.Y - 6}, rl.Vector2{X: end.X + 6, Y: end.Y + 6}, 2, rl.Red)
+		rl.DrawLineEx(rl.Vector2{X: end.X - 6, Y: end.Y + 6}, rl.Vector2{X: end.X + 6, Y: end.Y - 6}, 2, rl.Red)
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"math"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+const WHITE_HOLE_FORCE float32 = 3000.0
+
+type WhiteHole struct {
+	game          *Game
+	pos           rl.Vector2
+	initialRadius float32
+	radius        float32
+	force         float32
+	angle         float32
+}
+
+func initWhiteHole(g *Game, p rl.Vector2, r float32) WhiteHole {
+	return WhiteHole{
+		game:          g,
+		pos:           p,
+		initialRadius: r,
+		radius:        r,
+		force:         WHITE_HOLE_FORCE,
+		angle:         g.rng.Float32() * 2 * math.Pi,
+	}
+}
+
+func (w *WhiteHole) render() {
+	scale := w.radius / w.initialRadius
+	sprite := w.game.blackHoleSprite
+	scaledTWidth := RENDER_SCALE * sprite.width() * scale
+	scaledTHeight := RENDER_SCALE * sprite.height() * scale
+	sprite.draw(
+		rl.NewRectangle(w.pos.X, w.pos.Y, scaledTWidth, scaledTHeight),
+		rl.Vector2{
+			X: scaledTWidth / 2,
+			Y: scaledTHeight / 2,
+		},
+		w.angle*(180/math.Pi),
+		rl.Fade(rl.RayWhite, 0.8),
+	)
+	rl.DrawCircleV(w.pos, DEATH_RADIUS_RATIO*w.radius, rl.Fade(rl.RayWhite, 0.6))
+}
+
+func (w *WhiteHole) update() {
+	w.radius -= decayRate
+	w.angle -= math.Pi / 60
+}
+
+// Pushes objects away, the reverse of a black hole
+func (w *WhiteHole) calculateForceOnObject(obj rl.Vector2) rl.Vector2 {
+	angle := math.Atan2(float64(obj.Y-w.pos.Y), float64(obj.X-w.pos.X))
+	dis := math.Sqrt(math.Pow(float64(w.pos.Y-obj.Y), 2) + math.Pow(float64(w.pos.X-obj.X), 2))
+
+	// Nothing can fall into a white hole, so keep the push finite at the centre
+	dis = math.Max(dis, float64(DEATH_RADIUS_RATIO*w.radius))
+
+	gForce := float64(w.force) / (FUDGE_FACTOR * math.Pow(dis, 2))
+	return rl.Vector2{X: float32(math.Cos(angle) * gForce), Y: float32(math.Sin(angle) * gForce)}
+}
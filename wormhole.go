@@ -0,0 +1,81 @@
+package main
+
+import (
+	"math"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+const WORMHOLE_RADIUS float32 = 20.0
+const WORMHOLE_FORCE float32 = 800.0
+const WORMHOLE_LIFETIME int32 = 600
+const WORMHOLE_EXIT_DISTANCE float32 = 2.5
+
+// A linked pair of mouths; anything entering one leaves the other with the same velocity
+type Wormhole struct {
+	game     *Game
+	mouths   [2]rl.Vector2
+	radius   float32
+	angle    float32
+	lifetime int32
+}
+
+func initWormhole(g *Game, a rl.Vector2, b rl.Vector2) Wormhole {
+	return Wormhole{
+		game:     g,
+		mouths:   [2]rl.Vector2{a, b},
+		radius:   WORMHOLE_RADIUS,
+		angle:    g.rng.Float32() * 2 * math.Pi,
+		lifetime: WORMHOLE_LIFETIME,
+	}
+}
+
+func (w *Wormhole) render() {
+	// Fade out over the last second
+	alpha := float32(math.Min(1, float64(w.lifetime)/60))
+	rl.DrawLineEx(w.mouths[0], w.mouths[1], 1, rl.Fade(rl.Purple, 0.15*alpha))
+	for i, mouth := range w.mouths {
+		ringAngle := w.angle
+		if i == 1 {
+			ringAngle = -ringAngle
+		}
+		for ring := range 3 {
+			r := w.radius * (1 - 0.25*float32(ring))
+			wobble := float32(math.Sin(float64(ringAngle)+float64(ring))) * 2
+			rl.DrawCircleLines(int32(mouth.X), int32(mouth.Y), r+wobble, rl.Fade(rl.Violet, alpha))
+		}
+	}
+}
+
+func (w *Wormhole) update() {
+	w.angle += math.Pi / 30
+	w.lifetime -= 1
+}
+
+// Both mouths pull gently so objects drift in
+func (w *Wormhole) calculateForceOnObject(obj rl.Vector2) rl.Vector2 {
+	force := rl.Vector2{}
+	for _, mouth := range w.mouths {
+		angle := math.Atan2(float64(mouth.Y-obj.Y), float64(mouth.X-obj.X))
+		dis := math.Max(float64(w.radius), float64(rl.Vector2Distance(mouth, obj)))
+		gForce := float64(WORMHOLE_FORCE) / (FUDGE_FACTOR * math.Pow(dis, 2))
+		force = rl.Vector2Add(force, rl.Vector2{X: float32(math.Cos(angle) * gForce), Y: float32(math.Sin(angle) * gForce)})
+	}
+	return force
+}
+
+// If pos is inside a mouth, return where the object comes out of the other one
+func (w *Wormhole) teleport(pos rl.Vector2, velocity rl.Vector2) (rl.Vector2, bool) {
+	for i, mouth := range w.mouths {
+		if rl.Vector2Distance(pos, mouth) < w.radius {
+			// Leave along the direction of travel, clear of the exit mouth so we don't bounce straight back
+			direction := rl.Vector2Normalize(velocity)
+			if rl.Vector2Length(direction) == 0 {
+				direction = rl.Vector2{X: 1, Y: 0}
+			}
+			exit := w.mouths[1-i]
+			return rl.Vector2Add(exit, rl.Vector2Scale(direction, w.radius*WORMHOLE_EXIT_DISTANCE)), true
+		}
+	}
+	return pos, false
+}